@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/gaspartv/api.ecommerce/src/config"
+	"github.com/gaspartv/api.ecommerce/src/external/storage"
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"github.com/gaspartv/api.ecommerce/src/internal/importer"
+	"github.com/gaspartv/api.ecommerce/src/internal/media"
+	"gorm.io/gorm"
+)
+
+// runCommand runs a maintenance subcommand instead of starting the API.
+func runCommand(name string, args []string, db *gorm.DB, store storage.ObjectStore, env *config.Env) error {
+	switch name {
+	case "media-reconcile":
+		return reconcileMedia(args, db, store, env)
+	case "product-import":
+		return importProducts(args, db, env)
+	default:
+		return fmt.Errorf("comando desconhecido %q", name)
+	}
+}
+
+// reconcileMedia prints the keys of the stored objects no row refers to.
+// They are only deleted with -apply.
+func reconcileMedia(args []string, db *gorm.DB, store storage.ObjectStore, env *config.Env) error {
+	flags := flag.NewFlagSet("media-reconcile", flag.ContinueOnError)
+	apply := flags.Bool("apply", false, "remove os objetos órfãos; sem esta opção eles só são listados")
+	minAge := flags.Duration("min-age", env.MediaReconcileMinAge, "ignora objetos mais novos que esse tempo")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	report, err := media.Reconcile(context.Background(), db, store, media.ReconcileOptions{
+		DryRun:       !*apply,
+		MinAge:       *minAge,
+		DefaultImage: env.IMAGE_CATEGORY_DEFAULT_URL,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, key := range report.Orphaned {
+		fmt.Println(key)
+	}
+
+	log.Printf("%d objetos analisados, %d recentes ignorados, %d órfãos, %d removidos, %d falhas",
+		report.Scanned, report.Recent, len(report.Orphaned), report.Deleted, report.Failed)
+	return nil
+}
+
+// importProducts runs a product import from a CSV or XLSX file in the
+// foreground and prints the per-row report.
+func importProducts(args []string, db *gorm.DB, env *config.Env) error {
+	flags := flag.NewFlagSet("product-import", flag.ContinueOnError)
+	path := flags.String("file", "", "planilha CSV ou XLSX com os produtos")
+	validateOnly := flags.Bool("validate-only", false, "apenas valida a planilha, sem gravar")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *path == "" {
+		return errors.New("informe a planilha com -file")
+	}
+
+	data, err := os.ReadFile(*path)
+	if err != nil {
+		return err
+	}
+
+	rows, err := importer.Parse(*path, data)
+	if err != nil {
+		return err
+	}
+
+	job := entity.NewProductImport(nil, filepath.Base(*path), *validateOnly)
+	job.TotalRows = len(rows)
+	if err := db.Create(job).Error; err != nil {
+		return err
+	}
+
+	importer.Run(db, env, job, rows)
+
+	for _, rowError := range job.Errors {
+		fmt.Printf("linha %d (%s): %s\n", rowError.Line, rowError.Sku, rowError.Message)
+	}
+
+	log.Printf("Importação %s: %s (%s)", job.ID, job.Status, job.Message)
+	if job.Status == entity.ProductImportFailed {
+		return errors.New("a importação falhou")
+	}
+	return nil
+}
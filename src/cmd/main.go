@@ -1,13 +1,23 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"os"
 
 	"github.com/gaspartv/api.ecommerce/src/config"
+	"github.com/gaspartv/api.ecommerce/src/external/mailer"
+	"github.com/gaspartv/api.ecommerce/src/external/notifier"
+	"github.com/gaspartv/api.ecommerce/src/external/payment"
 	"github.com/gaspartv/api.ecommerce/src/external/storage"
 	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"github.com/gaspartv/api.ecommerce/src/internal/handler"
+	"github.com/gaspartv/api.ecommerce/src/internal/inventory"
+	"github.com/gaspartv/api.ecommerce/src/internal/media"
+	"github.com/gaspartv/api.ecommerce/src/internal/password"
 	"github.com/gaspartv/api.ecommerce/src/internal/routes"
+	"github.com/gaspartv/api.ecommerce/src/internal/seed"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"gorm.io/driver/postgres"
@@ -35,11 +45,62 @@ func main() {
 	if err != nil {
 		log.Fatal("Erro ao conectar:", err)
 	}
-	db.AutoMigrate(&entity.Category{})
+	db.AutoMigrate(&entity.Category{}, &entity.Session{}, &entity.Role{}, &entity.Permission{}, &entity.PasswordReset{}, &entity.Cart{}, &entity.CartItem{}, &entity.Order{}, &entity.OrderItem{}, &entity.Payment{}, &entity.InventoryMovement{}, &entity.StockReservation{}, &entity.Warehouse{}, &entity.WarehouseStock{}, &entity.OrderItemAllocation{}, &entity.ProductOptionType{}, &entity.ProductOptionValue{}, &entity.ProductVariant{}, &entity.SlugHistory{}, &entity.ProductImage{}, &entity.Upload{}, &entity.ProductImport{})
 
-	r2, err := storage.NewR2Client(env)
+	if err := seed.Roles(db, env); err != nil {
+		log.Fatal("Erro ao criar papéis padrão:", err)
+	}
+
+	if err := seed.Warehouses(db); err != nil {
+		log.Fatal("Erro ao criar o depósito padrão:", err)
+	}
+
+	store, err := storage.New(env)
+	if err != nil {
+		log.Fatal("Erro ao configurar o armazenamento de arquivos:", err)
+	}
+
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], os.Args[2:], db, store, env); err != nil {
+			log.Fatal("Erro ao executar o comando:", err)
+		}
+		return
+	}
+
+	if env.ReservationSweepInterval > 0 {
+		inventory.StartReservationSweeper(context.Background(), db, env.ReservationSweepInterval)
+	}
+
+	if env.MediaReconcileInterval > 0 {
+		media.StartReconciler(context.Background(), db, store, env.MediaReconcileInterval, media.ReconcileOptions{
+			DryRun:       env.MediaReconcileDryRun,
+			MinAge:       env.MediaReconcileMinAge,
+			DefaultImage: env.IMAGE_CATEGORY_DEFAULT_URL,
+		})
+	}
+
+	mail, err := mailer.New(env)
 	if err != nil {
-		log.Fatal("Erro ao conectar no R2:", err)
+		log.Fatal("Erro ao configurar o envio de emails:", err)
+	}
+
+	lowStockNotifier, err := notifier.New(env, mail)
+	if err != nil {
+		log.Fatal("Erro ao configurar os alertas de estoque baixo:", err)
+	}
+
+	if env.LowStockScanInterval > 0 {
+		inventory.StartLowStockMonitor(context.Background(), db, lowStockNotifier, env.LowStockScanInterval)
+	}
+
+	passwords, err := password.NewPolicy(env)
+	if err != nil {
+		log.Fatal("Erro ao carregar a política de senhas:", err)
+	}
+
+	paymentProvider, err := payment.New(env)
+	if err != nil {
+		log.Fatal("Erro ao configurar o provedor de pagamento:", err)
 	}
 
 	router := gin.Default()
@@ -48,15 +109,28 @@ func main() {
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Content-Type", "Authorization", handler.CartTokenHeader},
+		ExposeHeaders:    []string{"Content-Length", handler.CartTokenHeader},
 		AllowCredentials: false,
 		MaxAge:           12 * 60 * 60,
 	}))
 
-	routes.CategoryRoutes(router, db, r2, env)
-	routes.ProductRoutes(router, db, r2, env)
-	routes.UserRoutes(router, db, r2, env)
+	// Arquivos do armazenamento local são servidos pela própria API
+	if local, ok := store.(*storage.LocalStore); ok {
+		uploads := router.Group(storage.LocalRoute, func(ctx *gin.Context) {
+			ctx.Header("Cache-Control", storage.ImmutableCacheControl)
+		})
+		uploads.Static("/", local.Dir())
+	}
+
+	routes.CategoryRoutes(router, db, store, env)
+	routes.ProductRoutes(router, db, store, env)
+	routes.UserRoutes(router, db, store, mail, passwords, env)
+	routes.CartRoutes(router, db, env)
+	routes.OrderRoutes(router, db, env)
+	routes.PaymentRoutes(router, db, paymentProvider, env)
+	routes.WarehouseRoutes(router, db, env)
+	routes.UploadRoutes(router, db, store, env)
 
 	router.Run(":" + env.Port)
 }
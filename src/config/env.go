@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/joho/godotenv"
@@ -14,12 +16,54 @@ type Env struct {
 	DatabasePass               string `validate:"required"`
 	DatabaseName               string `validate:"required"`
 	DatabasePort               string `validate:"required"`
-	R2AccessKey                string `validate:"required"`
-	R2SecretKey                string `validate:"required"`
-	R2Endpoint                 string `validate:"required"`
-	R2Bucket                   string `validate:"required"`
-	R2PublicURL                string `validate:"required"`
+	StorageDriver              string `validate:"oneof=r2 local memory"`
+	StorageLocalDir            string `validate:"required_if=StorageDriver local"`
+	StorageLocalURL            string `validate:"required_if=StorageDriver local,omitempty,url"`
+	R2AccessKey                string `validate:"required_if=StorageDriver r2"`
+	R2SecretKey                string `validate:"required_if=StorageDriver r2"`
+	R2Endpoint                 string `validate:"required_if=StorageDriver r2"`
+	R2Bucket                   string `validate:"required_if=StorageDriver r2"`
+	R2PublicURL                string `validate:"required_if=StorageDriver r2"`
 	IMAGE_CATEGORY_DEFAULT_URL string `validate:"required"`
+	JwtSecret                  string `validate:"required,min=32"`
+	JwtAccessTTL               time.Duration
+	JwtRefreshTTL              time.Duration
+	AdminEmail                 string
+	AppURL                     string `validate:"required,url"`
+	MailDriver                 string `validate:"oneof=smtp log"`
+	MailFrom                   string `validate:"required"`
+	MailLogFile                string
+	SMTPHost                   string `validate:"required_if=MailDriver smtp"`
+	SMTPPort                   string `validate:"required_if=MailDriver smtp"`
+	SMTPUser                   string
+	SMTPPass                   string
+	EmailVerificationTTL       time.Duration
+	PasswordResetURL           string `validate:"required,url"`
+	PasswordResetTTL           time.Duration
+	PasswordMinLength          int `validate:"min=1"`
+	PasswordMaxBytes           int `validate:"gtefield=PasswordMinLength,max=72"`
+	PasswordRequireUpper       bool
+	PasswordRequireLower       bool
+	PasswordRequireDigit       bool
+	PasswordRequireSymbol      bool
+	PasswordBreachedFile       string
+	PaymentProvider            string `validate:"oneof=fake"`
+	PaymentWebhookSecret       string `validate:"required"`
+	PaymentFakeBehavior        string `validate:"oneof=approve decline delay"`
+	PaymentFakeDelay           time.Duration
+	ReservationTTL             time.Duration
+	ReservationSweepInterval   time.Duration
+	FulfillmentStrategy        string `validate:"oneof=priority most_stock closest"`
+	LowStockNotifier           string `validate:"oneof=log webhook email"`
+	LowStockWebhookURL         string `validate:"required_if=LowStockNotifier webhook,omitempty,url"`
+	LowStockEmail              string `validate:"required_if=LowStockNotifier email,omitempty,email"`
+	LowStockScanInterval       time.Duration
+	ImageMaxBytes              int `validate:"min=1"`
+	UploadPresignTTL           time.Duration
+	ProductImportMaxBytes      int `validate:"min=1"`
+	MediaReconcileInterval     time.Duration
+	MediaReconcileMinAge       time.Duration
+	MediaReconcileDryRun       bool
 }
 
 func LoadEnv() (*Env, error) {
@@ -34,12 +78,96 @@ func LoadEnv() (*Env, error) {
 	env.DatabasePass = os.Getenv("DATABASE_PASSWORD")
 	env.DatabaseName = os.Getenv("DATABASE_NAME")
 	env.DatabasePort = os.Getenv("DATABASE_PORT")
+	env.StorageDriver = getString("STORAGE_DRIVER", "r2")
+	env.StorageLocalDir = getString("STORAGE_LOCAL_DIR", "./uploads")
 	env.R2AccessKey = os.Getenv("R2_ACCESS_KEY_ID")
 	env.R2SecretKey = os.Getenv("R2_SECRET_ACCESS_KEY")
 	env.R2Endpoint = os.Getenv("R2_ENDPOINT")
 	env.R2Bucket = os.Getenv("R2_BUCKET")
 	env.R2PublicURL = os.Getenv("R2_PUBLIC_URL")
 	env.IMAGE_CATEGORY_DEFAULT_URL = os.Getenv("IMAGE_CATEGORY_DEFAULT_URL")
+	env.JwtSecret = os.Getenv("JWT_SECRET")
+	env.AdminEmail = os.Getenv("ADMIN_EMAIL")
+	env.AppURL = getString("APP_URL", "http://localhost:"+env.Port)
+	env.StorageLocalURL = getString("STORAGE_LOCAL_URL", env.AppURL+"/uploads")
+	env.MailDriver = getString("MAIL_DRIVER", "log")
+	env.MailFrom = getString("MAIL_FROM", "no-reply@localhost")
+	env.MailLogFile = os.Getenv("MAIL_LOG_FILE")
+	env.SMTPHost = os.Getenv("SMTP_HOST")
+	env.SMTPPort = getString("SMTP_PORT", "587")
+	env.SMTPUser = os.Getenv("SMTP_USER")
+	env.SMTPPass = os.Getenv("SMTP_PASSWORD")
+	env.PasswordResetURL = getString("PASSWORD_RESET_URL", env.AppURL+"/reset-password")
+	env.PasswordBreachedFile = os.Getenv("PASSWORD_BREACHED_FILE")
+	env.PaymentProvider = getString("PAYMENT_PROVIDER", "fake")
+	env.PaymentWebhookSecret = os.Getenv("PAYMENT_WEBHOOK_SECRET")
+	env.PaymentFakeBehavior = getString("PAYMENT_FAKE_BEHAVIOR", "approve")
+	env.FulfillmentStrategy = getString("FULFILLMENT_STRATEGY", "priority")
+	env.LowStockNotifier = getString("LOW_STOCK_NOTIFIER", "log")
+	env.LowStockWebhookURL = os.Getenv("LOW_STOCK_WEBHOOK_URL")
+	env.LowStockEmail = os.Getenv("LOW_STOCK_EMAIL")
+
+	var err error
+	if env.JwtAccessTTL, err = getDuration("JWT_ACCESS_TTL", 15*time.Minute); err != nil {
+		return nil, err
+	}
+	if env.JwtRefreshTTL, err = getDuration("JWT_REFRESH_TTL", 30*24*time.Hour); err != nil {
+		return nil, err
+	}
+	if env.EmailVerificationTTL, err = getDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour); err != nil {
+		return nil, err
+	}
+	if env.PasswordResetTTL, err = getDuration("PASSWORD_RESET_TTL", time.Hour); err != nil {
+		return nil, err
+	}
+	if env.PaymentFakeDelay, err = getDuration("PAYMENT_FAKE_DELAY", 5*time.Second); err != nil {
+		return nil, err
+	}
+	if env.ReservationTTL, err = getDuration("RESERVATION_TTL", 15*time.Minute); err != nil {
+		return nil, err
+	}
+	if env.ReservationSweepInterval, err = getDuration("RESERVATION_SWEEP_INTERVAL", time.Minute); err != nil {
+		return nil, err
+	}
+	if env.LowStockScanInterval, err = getDuration("LOW_STOCK_SCAN_INTERVAL", 15*time.Minute); err != nil {
+		return nil, err
+	}
+	if env.UploadPresignTTL, err = getDuration("UPLOAD_PRESIGN_TTL", 15*time.Minute); err != nil {
+		return nil, err
+	}
+	if env.MediaReconcileInterval, err = getDuration("MEDIA_RECONCILE_INTERVAL", 0); err != nil {
+		return nil, err
+	}
+	if env.MediaReconcileMinAge, err = getDuration("MEDIA_RECONCILE_MIN_AGE", 24*time.Hour); err != nil {
+		return nil, err
+	}
+	if env.ImageMaxBytes, err = getInt("IMAGE_MAX_BYTES", 10<<20); err != nil {
+		return nil, err
+	}
+	if env.ProductImportMaxBytes, err = getInt("PRODUCT_IMPORT_MAX_BYTES", 20<<20); err != nil {
+		return nil, err
+	}
+	if env.PasswordMinLength, err = getInt("PASSWORD_MIN_LENGTH", 8); err != nil {
+		return nil, err
+	}
+	if env.PasswordMaxBytes, err = getInt("PASSWORD_MAX_LENGTH", 72); err != nil {
+		return nil, err
+	}
+	if env.PasswordRequireUpper, err = getBool("PASSWORD_REQUIRE_UPPER", true); err != nil {
+		return nil, err
+	}
+	if env.PasswordRequireLower, err = getBool("PASSWORD_REQUIRE_LOWER", true); err != nil {
+		return nil, err
+	}
+	if env.PasswordRequireDigit, err = getBool("PASSWORD_REQUIRE_DIGIT", true); err != nil {
+		return nil, err
+	}
+	if env.PasswordRequireSymbol, err = getBool("PASSWORD_REQUIRE_SYMBOL", false); err != nil {
+		return nil, err
+	}
+	if env.MediaReconcileDryRun, err = getBool("MEDIA_RECONCILE_DRY_RUN", true); err != nil {
+		return nil, err
+	}
 
 	validate := validator.New()
 	if err := validate.Struct(env); err != nil {
@@ -48,3 +176,38 @@ func LoadEnv() (*Env, error) {
 
 	return &env, nil
 }
+
+func getString(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return fallback
+}
+
+func getDuration(key string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	return time.ParseDuration(value)
+}
+
+func getInt(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	return strconv.Atoi(value)
+}
+
+func getBool(key string, fallback bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	return strconv.ParseBool(value)
+}
@@ -0,0 +1,52 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogMailer writes messages to a file, or to the standard logger when no
+// path is set, so the API can run without a mail server.
+type LogMailer struct {
+	mu   sync.Mutex
+	path string
+	from string
+}
+
+func NewLogMailer(path string, from string) *LogMailer {
+	return &LogMailer{
+		path: path,
+		from: from,
+	}
+}
+
+func (m *LogMailer) Send(message Message) error {
+	entry := fmt.Sprintf(
+		"=== %s ===\nFrom: %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().Format(time.RFC3339),
+		m.from,
+		message.To,
+		message.Subject,
+		message.Body,
+	)
+
+	if m.path == "" {
+		log.Print(entry)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	file, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(entry)
+	return err
+}
@@ -0,0 +1,28 @@
+package mailer
+
+import (
+	"fmt"
+
+	"github.com/gaspartv/api.ecommerce/src/config"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(message Message) error
+}
+
+func New(env *config.Env) (Mailer, error) {
+	switch env.MailDriver {
+	case "smtp":
+		return NewSMTPMailer(env), nil
+	case "log":
+		return NewLogMailer(env.MailLogFile, env.MailFrom), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", env.MailDriver)
+	}
+}
@@ -0,0 +1,42 @@
+package mailer
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+
+	"github.com/gaspartv/api.ecommerce/src/config"
+)
+
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPMailer(env *config.Env) *SMTPMailer {
+	var auth smtp.Auth
+	if env.SMTPUser != "" {
+		auth = smtp.PlainAuth("", env.SMTPUser, env.SMTPPass, env.SMTPHost)
+	}
+
+	return &SMTPMailer{
+		addr: net.JoinHostPort(env.SMTPHost, env.SMTPPort),
+		auth: auth,
+		from: env.MailFrom,
+	}
+}
+
+func (m *SMTPMailer) Send(message Message) error {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", m.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", message.To)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", message.Subject)
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=\"UTF-8\"\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(message.Body)
+
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{message.To}, []byte(msg.String()))
+}
@@ -0,0 +1,44 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gaspartv/api.ecommerce/src/external/mailer"
+)
+
+// EmailNotifier sends one digest email per scan to a fixed address.
+type EmailNotifier struct {
+	mailer mailer.Mailer
+	to     string
+}
+
+func NewEmailNotifier(mail mailer.Mailer, to string) *EmailNotifier {
+	return &EmailNotifier{
+		mailer: mail,
+		to:     to,
+	}
+}
+
+func (n *EmailNotifier) NotifyLowStock(ctx context.Context, alerts []LowStockAlert) error {
+	var body strings.Builder
+	body.WriteString("The following products reached their reorder point:\n\n")
+	for _, alert := range alerts {
+		fmt.Fprintf(
+			&body,
+			"- %s (SKU %s): %d in stock, reorder point %d, suggested reorder %d\n",
+			alert.Name,
+			alert.Sku,
+			alert.StockQuantity,
+			alert.ReorderPoint,
+			alert.ReorderQuantity,
+		)
+	}
+
+	return n.mailer.Send(mailer.Message{
+		To:      n.to,
+		Subject: fmt.Sprintf("Low stock alert: %d products", len(alerts)),
+		Body:    body.String(),
+	})
+}
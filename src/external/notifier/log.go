@@ -0,0 +1,27 @@
+package notifier
+
+import (
+	"context"
+	"log"
+)
+
+// LogNotifier writes alerts to the standard logger.
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) NotifyLowStock(ctx context.Context, alerts []LowStockAlert) error {
+	for _, alert := range alerts {
+		log.Printf(
+			"Estoque baixo: %s (%s) com %d unidades, ponto de reposição %d, repor %d",
+			alert.Name,
+			alert.Sku,
+			alert.StockQuantity,
+			alert.ReorderPoint,
+			alert.ReorderQuantity,
+		)
+	}
+	return nil
+}
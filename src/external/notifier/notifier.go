@@ -0,0 +1,38 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gaspartv/api.ecommerce/src/config"
+	"github.com/gaspartv/api.ecommerce/src/external/mailer"
+)
+
+// LowStockAlert describes a product, or a variant of it, whose stock
+// reached the product's reorder point.
+type LowStockAlert struct {
+	ProductID       string `json:"product_id"`
+	VariantID       string `json:"variant_id,omitempty"`
+	Name            string `json:"name"`
+	Sku             string `json:"sku"`
+	StockQuantity   int    `json:"stock_quantity"`
+	ReorderPoint    int    `json:"reorder_point"`
+	ReorderQuantity int    `json:"reorder_quantity"`
+}
+
+type Notifier interface {
+	NotifyLowStock(ctx context.Context, alerts []LowStockAlert) error
+}
+
+func New(env *config.Env, mail mailer.Mailer) (Notifier, error) {
+	switch env.LowStockNotifier {
+	case "log":
+		return NewLogNotifier(), nil
+	case "webhook":
+		return NewWebhookNotifier(env.LowStockWebhookURL), nil
+	case "email":
+		return NewEmailNotifier(mail, env.LowStockEmail), nil
+	default:
+		return nil, fmt.Errorf("unknown low stock notifier %q", env.LowStockNotifier)
+	}
+}
@@ -0,0 +1,176 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nrednav/cuid2"
+)
+
+const SignatureHeader = "X-Payment-Signature"
+
+type FakeBehavior string
+
+const (
+	FakeApprove FakeBehavior = "approve"
+	FakeDecline FakeBehavior = "decline"
+	FakeDelay   FakeBehavior = "delay"
+)
+
+type fakePayment struct {
+	orderID string
+	amount  float64
+	status  Status
+}
+
+// FakeGateway is an in-process PaymentProvider for local runs and offline
+// end-to-end tests. The outcome of an authorization comes, in order, from
+// the queue filled by Script, from a "fake_<behavior>" payment token, or from
+// the default behavior. Delayed authorizations stay pending and are settled
+// later through a signed call to the webhook URL, like a real gateway.
+type FakeGateway struct {
+	mu         sync.Mutex
+	secret     []byte
+	behavior   FakeBehavior
+	delay      time.Duration
+	webhookURL string
+	script     []FakeBehavior
+	payments   map[string]*fakePayment
+}
+
+func NewFakeGateway(secret string, behavior FakeBehavior, delay time.Duration, webhookURL string) *FakeGateway {
+	return &FakeGateway{
+		secret:     []byte(secret),
+		behavior:   behavior,
+		delay:      delay,
+		webhookURL: webhookURL,
+		payments:   map[string]*fakePayment{},
+	}
+}
+
+func (g *FakeGateway) Name() string {
+	return "fake"
+}
+
+// Script queues the outcomes of the next authorizations.
+func (g *FakeGateway) Script(behaviors ...FakeBehavior) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.script = append(g.script, behaviors...)
+}
+
+func (g *FakeGateway) Authorize(ctx context.Context, request AuthorizeRequest) (*Result, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	behavior := g.behavior
+	if len(g.script) > 0 {
+		behavior = g.script[0]
+		g.script = g.script[1:]
+	} else if scripted, ok := strings.CutPrefix(request.Token, "fake_"); ok {
+		behavior = FakeBehavior(scripted)
+	}
+
+	reference := "fake_" + cuid2.Generate()
+	payment := &fakePayment{orderID: request.OrderID, amount: request.Amount}
+	g.payments[reference] = payment
+
+	switch behavior {
+	case FakeDecline:
+		payment.status = StatusDeclined
+		return &Result{Reference: reference, Status: StatusDeclined, Message: "Card declined"}, nil
+	case FakeDelay:
+		payment.status = StatusPending
+		go g.settleLater(reference)
+		return &Result{Reference: reference, Status: StatusPending, Message: "Authorization pending"}, nil
+	default:
+		payment.status = StatusAuthorized
+		return &Result{Reference: reference, Status: StatusAuthorized, Message: "Approved"}, nil
+	}
+}
+
+func (g *FakeGateway) Capture(ctx context.Context, reference string, amount float64) (*Result, error) {
+	return g.move(reference, StatusAuthorized, StatusCaptured)
+}
+
+func (g *FakeGateway) Refund(ctx context.Context, reference string, amount float64) (*Result, error) {
+	return g.move(reference, StatusCaptured, StatusRefunded)
+}
+
+func (g *FakeGateway) VerifyWebhook(payload []byte, signature string) (*Event, error) {
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, g.sign(payload)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+func (g *FakeGateway) move(reference string, from Status, to Status) (*Result, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	payment, ok := g.payments[reference]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	if payment.status != from {
+		return nil, ErrInvalidState
+	}
+
+	payment.status = to
+	return &Result{Reference: reference, Status: to}, nil
+}
+
+func (g *FakeGateway) settleLater(reference string) {
+	time.Sleep(g.delay)
+
+	g.mu.Lock()
+	payment := g.payments[reference]
+	payment.status = StatusAuthorized
+	event := Event{Reference: reference, OrderID: payment.orderID, Status: StatusAuthorized}
+	g.mu.Unlock()
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Println("Erro ao montar webhook de pagamento:", err)
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, g.webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		log.Println("Erro ao montar webhook de pagamento:", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, hex.EncodeToString(g.sign(payload)))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Println("Erro ao enviar webhook de pagamento:", err)
+		return
+	}
+	resp.Body.Close()
+}
+
+func (g *FakeGateway) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, g.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
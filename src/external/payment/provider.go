@@ -0,0 +1,85 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gaspartv/api.ecommerce/src/config"
+)
+
+type Status string
+
+const (
+	StatusPending    Status = "pending"
+	StatusAuthorized Status = "authorized"
+	StatusDeclined   Status = "declined"
+	StatusCaptured   Status = "captured"
+	StatusRefunded   Status = "refunded"
+)
+
+// transitions lists the statuses a payment may move to. Webhooks can arrive
+// late or out of order, so any other change is a regression.
+var transitions = map[Status][]Status{
+	StatusPending:    {StatusAuthorized, StatusDeclined},
+	StatusAuthorized: {StatusCaptured},
+	StatusCaptured:   {StatusRefunded},
+	StatusDeclined:   {},
+	StatusRefunded:   {},
+}
+
+func (s Status) CanTransitionTo(next Status) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrNotFound         = errors.New("payment not found")
+	ErrInvalidState     = errors.New("payment is not in a valid state for this operation")
+)
+
+type AuthorizeRequest struct {
+	OrderID string
+	Amount  float64
+	Token   string
+}
+
+type Result struct {
+	Reference string
+	Status    Status
+	Message   string
+}
+
+// Event is the provider notification delivered to the webhook endpoint.
+type Event struct {
+	Reference string `json:"reference"`
+	OrderID   string `json:"order_id"`
+	Status    Status `json:"status"`
+}
+
+type PaymentProvider interface {
+	Name() string
+	Authorize(ctx context.Context, request AuthorizeRequest) (*Result, error)
+	Capture(ctx context.Context, reference string, amount float64) (*Result, error)
+	Refund(ctx context.Context, reference string, amount float64) (*Result, error)
+	VerifyWebhook(payload []byte, signature string) (*Event, error)
+}
+
+func New(env *config.Env) (PaymentProvider, error) {
+	switch env.PaymentProvider {
+	case "fake":
+		return NewFakeGateway(
+			env.PaymentWebhookSecret,
+			FakeBehavior(env.PaymentFakeBehavior),
+			env.PaymentFakeDelay,
+			env.AppURL+"/payments/webhook",
+		), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", env.PaymentProvider)
+	}
+}
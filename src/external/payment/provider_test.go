@@ -0,0 +1,21 @@
+package payment
+
+import "testing"
+
+func TestStatusTransitions(t *testing.T) {
+	statuses := []Status{StatusPending, StatusAuthorized, StatusDeclined, StatusCaptured, StatusRefunded}
+
+	allowed := map[Status]map[Status]bool{
+		StatusPending:    {StatusAuthorized: true, StatusDeclined: true},
+		StatusAuthorized: {StatusCaptured: true},
+		StatusCaptured:   {StatusRefunded: true},
+	}
+
+	for _, from := range statuses {
+		for _, to := range statuses {
+			if got, want := from.CanTransitionTo(to), allowed[from][to]; got != want {
+				t.Errorf("%s -> %s = %t, want %t", from, to, got, want)
+			}
+		}
+	}
+}
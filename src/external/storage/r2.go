@@ -2,9 +2,9 @@ package storage
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"io"
-	"os"
+	"time"
 
 	projectConfig "github.com/gaspartv/api.ecommerce/src/config"
 
@@ -12,6 +12,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
 )
 
 func NewR2Client(env *projectConfig.Env) (*s3.Client, error) {
@@ -31,19 +32,140 @@ func NewR2Client(env *projectConfig.Env) (*s3.Client, error) {
 	return s3.NewFromConfig(cfg), nil
 }
 
-func UploadToR2(client *s3.Client, key string, contentType string, body io.Reader) (string, error) {
-	bucket := os.Getenv("R2_BUCKET")
-	publicBase := os.Getenv("R2_PUBLIC_URL")
+// R2Store keeps objects in a Cloudflare R2 (or any S3 compatible) bucket
+// served from a public base URL.
+type R2Store struct {
+	client    *s3.Client
+	bucket    string
+	publicURL string
+}
+
+func NewR2Store(env *projectConfig.Env) (*R2Store, error) {
+	client, err := NewR2Client(env)
+	if err != nil {
+		return nil, err
+	}
 
-	_, err := client.PutObject(context.TODO(), &s3.PutObjectInput{
-		Bucket:      aws.String(bucket),
+	return &R2Store{
+		client:    client,
+		bucket:    env.R2Bucket,
+		publicURL: env.R2PublicURL,
+	}, nil
+}
+
+func (s *R2Store) Put(ctx context.Context, key string, body io.Reader, options PutOptions) error {
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
 		Key:         aws.String(key),
 		Body:        body,
-		ContentType: aws.String(contentType),
+		ContentType: aws.String(options.ContentType),
+	}
+
+	if options.CacheControl != "" {
+		input.CacheControl = aws.String(options.CacheControl)
+	}
+
+	_, err := s.client.PutObject(ctx, input)
+	return err
+}
+
+func (s *R2Store) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	output, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
 	})
+	if err != nil {
+		return nil, nil, notFound(err)
+	}
+
+	return output.Body, &ObjectInfo{
+		Key:          key,
+		Size:         aws.ToInt64(output.ContentLength),
+		ContentType:  aws.ToString(output.ContentType),
+		CacheControl: aws.ToString(output.CacheControl),
+		LastModified: aws.ToTime(output.LastModified),
+	}, nil
+}
+
+func (s *R2Store) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+func (s *R2Store) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	output, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return &ObjectInfo{
+		Key:          key,
+		Size:         aws.ToInt64(output.ContentLength),
+		ContentType:  aws.ToString(output.ContentType),
+		CacheControl: aws.ToString(output.CacheControl),
+		LastModified: aws.ToTime(output.LastModified),
+	}, nil
+}
+
+func (s *R2Store) List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, object := range page.Contents {
+			err := fn(ObjectInfo{
+				Key:          aws.ToString(object.Key),
+				Size:         aws.ToInt64(object.Size),
+				LastModified: aws.ToTime(object.LastModified),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *R2Store) PresignPut(ctx context.Context, key string, contentType string, size int64, ttl time.Duration) (string, error) {
+	request, err := s3.NewPresignClient(s.client).PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(key),
+		ContentType:   aws.String(contentType),
+		ContentLength: aws.Int64(size),
+	}, s3.WithPresignExpires(ttl))
 	if err != nil {
 		return "", err
 	}
 
-	return fmt.Sprintf("%s/%s", publicBase, key), nil
+	return request.URL, nil
+}
+
+func (s *R2Store) URL(key string) string {
+	return joinURL(s.publicURL, key)
+}
+
+// notFound maps the S3 missing-object errors to ErrNotFound.
+func notFound(err error) error {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "NotFound", "NoSuchKey":
+			return ErrNotFound
+		}
+	}
+	return err
 }
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gaspartv/api.ecommerce/src/config"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// ImmutableCacheControl is for objects whose key changes with their
+// content, so caches may keep them forever.
+const ImmutableCacheControl = "public, max-age=31536000, immutable"
+
+type PutOptions struct {
+	ContentType  string
+	CacheControl string
+}
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	CacheControl string
+	LastModified time.Time
+}
+
+// ObjectStore keeps uploaded files under slash-separated keys and knows the
+// public URL each one is served from.
+type ObjectStore interface {
+	Put(ctx context.Context, key string, body io.Reader, options PutOptions) error
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	Delete(ctx context.Context, key string) error
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error
+	URL(key string) string
+}
+
+func New(env *config.Env) (ObjectStore, error) {
+	switch env.StorageDriver {
+	case "r2":
+		return NewR2Store(env)
+	case "local":
+		return NewLocalStore(env.StorageLocalDir, env.StorageLocalURL)
+	case "memory":
+		return NewMemoryStore(env.StorageLocalURL), nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", env.StorageDriver)
+	}
+}
+
+// KeyFromURL returns the key of an object served by store, or false when
+// url points somewhere else.
+func KeyFromURL(store ObjectStore, url string) (string, bool) {
+	key, ok := strings.CutPrefix(url, store.URL(""))
+	if !ok || key == "" {
+		return "", false
+	}
+	return key, true
+}
+
+func joinURL(base string, key string) string {
+	return strings.TrimRight(base, "/") + "/" + key
+}
+
+// Presigner is implemented by stores that let clients upload straight to
+// them. The returned URL only accepts a PUT with exactly the given content
+// type and length.
+type Presigner interface {
+	PresignPut(ctx context.Context, key string, contentType string, size int64, ttl time.Duration) (string, error)
+}
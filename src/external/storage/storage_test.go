@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testStore runs the ObjectStore contract shared by every backend.
+func testStore(t *testing.T, store ObjectStore) {
+	ctx := context.Background()
+
+	put := func(key string, body string) {
+		t.Helper()
+		err := store.Put(ctx, key, strings.NewReader(body), PutOptions{ContentType: "image/png", CacheControl: ImmutableCacheControl})
+		if err != nil {
+			t.Fatalf("Put(%q): %v", key, err)
+		}
+	}
+
+	put("products/a.png", "first")
+	put("products/b.png", "second")
+	put("users/c.png", "third")
+	put("products/a.png", "replaced")
+
+	reader, info, err := store.Get(ctx, "products/a.png")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "replaced" {
+		t.Errorf("Get data = %q, want %q", data, "replaced")
+	}
+
+	if info.Size != int64(len("replaced")) || info.ContentType != "image/png" {
+		t.Errorf("Get info = %+v", info)
+	}
+
+	stat, err := store.Stat(ctx, "users/c.png")
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if stat.Key != "users/c.png" || stat.Size != int64(len("third")) {
+		t.Errorf("Stat = %+v", stat)
+	}
+
+	var listed []string
+	if err := store.List(ctx, "products/", func(info ObjectInfo) error {
+		listed = append(listed, info.Key)
+		return nil
+	}); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if want := []string{"products/a.png", "products/b.png"}; !reflect.DeepEqual(listed, want) {
+		t.Errorf("List = %v, want %v", listed, want)
+	}
+
+	stop := errors.New("stop")
+	if err := store.List(ctx, "", func(ObjectInfo) error { return stop }); !errors.Is(err, stop) {
+		t.Errorf("List callback error = %v, want %v", err, stop)
+	}
+
+	if err := store.Delete(ctx, "products/a.png"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if _, err := store.Stat(ctx, "products/a.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat after delete = %v, want ErrNotFound", err)
+	}
+
+	if _, _, err := store.Get(ctx, "missing.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get missing = %v, want ErrNotFound", err)
+	}
+
+	if err := store.Delete(ctx, "missing.png"); err != nil {
+		t.Errorf("Delete missing = %v, want nil", err)
+	}
+
+	url := store.URL("users/c.png")
+	if key, ok := KeyFromURL(store, url); !ok || key != "users/c.png" {
+		t.Errorf("KeyFromURL(%q) = %q, %t", url, key, ok)
+	}
+
+	if _, ok := KeyFromURL(store, "https://elsewhere.example/users/c.png"); ok {
+		t.Error("KeyFromURL accepted a foreign URL")
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore("http://localhost/uploads/"))
+}
+
+func TestLocalStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalStore(dir, "http://localhost/uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testStore(t, store)
+
+	// Keys are resolved inside the directory, so ".." cannot climb out.
+	if err := store.Put(context.Background(), "../escape.png", strings.NewReader("x"), PutOptions{}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escape.png")); err != nil {
+		t.Errorf("escaping key was not kept inside the directory: %v", err)
+	}
+
+	if err := store.Put(context.Background(), "/", strings.NewReader("x"), PutOptions{}); err == nil {
+		t.Error("Put with an empty key should fail")
+	}
+}
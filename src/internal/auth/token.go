@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/gaspartv/api.ecommerce/src/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+const PurposeEmailVerification = "email_verification"
+
+type AccessClaims struct {
+	SessionID string `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+func NewAccessToken(userID string, sessionID string, env *config.Env) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(env.JwtAccessTTL)
+
+	claims := AccessClaims{
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(env.JwtSecret))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return signed, expiresAt, nil
+}
+
+func ParseAccessToken(token string, env *config.Env) (*AccessClaims, error) {
+	claims := &AccessClaims{}
+
+	parsed, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(env.JwtSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !parsed.Valid || claims.Subject == "" || claims.SessionID == "" {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// ActionClaims back single-purpose links sent by email. The email is part of
+// the signed payload so a link stops working once the address changes.
+type ActionClaims struct {
+	Purpose string `json:"purpose"`
+	Email   string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+func NewActionToken(userID string, email string, purpose string, ttl time.Duration, env *config.Env) (string, error) {
+	now := time.Now()
+
+	claims := ActionClaims{
+		Purpose: purpose,
+		Email:   email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(env.JwtSecret))
+}
+
+func ParseActionToken(token string, purpose string, env *config.Env) (*ActionClaims, error) {
+	claims := &ActionClaims{}
+
+	parsed, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(env.JwtSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !parsed.Valid || claims.Subject == "" || claims.Purpose != purpose {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// NewOpaqueToken returns a random token for the client and the hash that
+// should be persisted; the raw value is never stored.
+func NewOpaqueToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	return token, HashOpaqueToken(token), nil
+}
+
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,82 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/nrednav/cuid2"
+)
+
+type Cart struct {
+	ID        string     `gorm:"type:varchar(32);primaryKey" json:"id"`
+	CreatedAt time.Time  `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+	UpdatedAt *time.Time `gorm:"type:timestamptz" json:"updated_at,omitempty"`
+	UserID    *string    `gorm:"type:varchar(32);uniqueIndex" json:"user_id,omitempty"`
+	TokenHash *string    `gorm:"type:varchar(64);uniqueIndex" json:"-"`
+	Items     []CartItem `gorm:"foreignKey:CartID;constraint:OnDelete:CASCADE" json:"items"`
+}
+
+type CartItem struct {
+	ID        string          `gorm:"type:varchar(32);primaryKey" json:"id"`
+	CreatedAt time.Time       `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+	UpdatedAt *time.Time      `gorm:"type:timestamptz" json:"updated_at,omitempty"`
+	CartID    string          `gorm:"type:varchar(32);not null;uniqueIndex:uq_cart_items_cart_product" json:"cart_id"`
+	ProductID string          `gorm:"type:varchar(32);not null;uniqueIndex:uq_cart_items_cart_product" json:"product_id"`
+	VariantID *string         `gorm:"type:varchar(32);uniqueIndex:uq_cart_items_cart_product,expression:COALESCE(variant_id\\,'')" json:"variant_id,omitempty"`
+	Quantity  int             `gorm:"type:int;not null" json:"quantity"`
+	UnitPrice float64         `gorm:"type:numeric(10,2);not null" json:"unit_price"`
+	Product   *Product        `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Variant   *ProductVariant `gorm:"foreignKey:VariantID" json:"variant,omitempty"`
+}
+
+type CartAddItem struct {
+	ProductID string  `json:"product_id" binding:"required"`
+	VariantID *string `json:"variant_id,omitempty"`
+	Quantity  int     `json:"quantity" binding:"required,min=1"`
+}
+
+type CartUpdateItem struct {
+	Quantity int `json:"quantity" binding:"required,min=1"`
+}
+
+func NewUserCart(userID string) *Cart {
+	return &Cart{
+		ID:     cuid2.Generate(),
+		UserID: &userID,
+		Items:  []CartItem{},
+	}
+}
+
+func NewGuestCart(tokenHash string) *Cart {
+	return &Cart{
+		ID:        cuid2.Generate(),
+		TokenHash: &tokenHash,
+		Items:     []CartItem{},
+	}
+}
+
+// NewCartItem snapshots the product price, or the variant price when a
+// variant is picked, at the moment it is added.
+func NewCartItem(cartID string, product *Product, variant *ProductVariant, quantity int) *CartItem {
+	item := &CartItem{
+		ID:        cuid2.Generate(),
+		CartID:    cartID,
+		ProductID: product.ID,
+		Quantity:  quantity,
+		UnitPrice: product.Price,
+	}
+
+	if variant != nil {
+		item.VariantID = &variant.ID
+		item.UnitPrice = variant.EffectivePrice(product)
+	}
+
+	return item
+}
+
+func (c *Cart) Total() float64 {
+	var total float64
+	for _, item := range c.Items {
+		total += item.UnitPrice * float64(item.Quantity)
+	}
+	return total
+}
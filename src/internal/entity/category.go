@@ -9,14 +9,31 @@ import (
 )
 
 type Category struct {
-	ID          string         `gorm:"type:varchar(32);primaryKey" json:"id"`
-	CreatedAt   time.Time      `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
-	UpdatedAt   *time.Time     `gorm:"type:timestamptz" json:"updated_at,omitempty"`
-	DisabledAt  *time.Time     `gorm:"type:timestamptz" json:"disabled_at,omitempty"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
-	Name        string         `gorm:"type:varchar(255);not null;uniqueIndex" json:"name"`
-	Description string         `gorm:"type:varchar(510)" json:"description"`
-	Image       string         `gorm:"type:varchar(255)" json:"image"`
+	ID            string         `gorm:"type:varchar(32);primaryKey" json:"id"`
+	CreatedAt     time.Time      `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+	UpdatedAt     *time.Time     `gorm:"type:timestamptz" json:"updated_at,omitempty"`
+	DisabledAt    *time.Time     `gorm:"type:timestamptz" json:"disabled_at,omitempty"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	Name          string         `gorm:"type:varchar(255);not null;uniqueIndex" json:"name"`
+	Slug          string         `gorm:"type:varchar(255);not null;uniqueIndex:uq_categories_slug_not_deleted,where:deleted_at IS NULL" json:"slug"`
+	Description   string         `gorm:"type:varchar(510)" json:"description"`
+	Image         string         `gorm:"type:varchar(255)" json:"image"`
+	ImageVariants ImageVariants  `gorm:"type:jsonb" json:"image_variants,omitempty"`
+	ParentID      *string        `gorm:"type:varchar(32);index" json:"parent_id,omitempty"`
+}
+
+// CategoryNode is a category with its subcategories, as returned by the
+// tree endpoint.
+type CategoryNode struct {
+	Category
+	Children []*CategoryNode `json:"children"`
+}
+
+// CategoryBreadcrumb is one step of the path from a root category.
+type CategoryBreadcrumb struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
 }
 
 type CategorySelect struct {
@@ -25,13 +42,17 @@ type CategorySelect struct {
 }
 
 type CategoryCreate struct {
-	Name        string `json:"name" binding:"required"`
-	Description string `json:"description" binding:"required"`
+	Name        string  `json:"name" binding:"required"`
+	Description string  `json:"description" binding:"required"`
+	ParentID    *string `json:"parent_id,omitempty"`
 }
 
+// CategoryEdit moves the category to the root when ParentID is an empty
+// string.
 type CategoryEdit struct {
 	Name        *string `json:"name,omitempty"`
 	Description *string `json:"description,omitempty"`
+	ParentID    *string `json:"parent_id,omitempty"`
 }
 
 type CategoryChangeImage struct {
@@ -44,5 +65,6 @@ func NewCategory(create CategoryCreate, env *config.Env) *Category {
 		Name:        create.Name,
 		Description: create.Description,
 		Image:       env.IMAGE_CATEGORY_DEFAULT_URL,
+		ParentID:    create.ParentID,
 	}
 }
@@ -0,0 +1,71 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/nrednav/cuid2"
+)
+
+type InventoryReason string
+
+const (
+	InventoryPurchase   InventoryReason = "purchase"
+	InventorySale       InventoryReason = "sale"
+	InventoryReturn     InventoryReason = "return"
+	InventoryAdjustment InventoryReason = "adjustment"
+	InventoryDamage     InventoryReason = "damage"
+	InventoryTransfer   InventoryReason = "transfer"
+)
+
+// ValidQuantity checks the sign of a movement against its reason: stock
+// only comes in through purchases and returns and only leaves through sales
+// and damage, while adjustments and transfer legs may go either way.
+func (r InventoryReason) ValidQuantity(quantity int) bool {
+	switch r {
+	case InventoryPurchase, InventoryReturn:
+		return quantity > 0
+	case InventorySale, InventoryDamage:
+		return quantity < 0
+	case InventoryAdjustment, InventoryTransfer:
+		return quantity != 0
+	default:
+		return false
+	}
+}
+
+// InventoryMovement is an append-only ledger entry for one warehouse.
+// products.stock_quantity is kept equal to the sum of a product's movements
+// without a variant and BalanceAfter is that product-wide total after the
+// movement. Variant stock is not split across warehouses, so a variant
+// movement has no warehouse and its BalanceAfter is the variant's own stock.
+type InventoryMovement struct {
+	ID           string          `gorm:"type:varchar(32);primaryKey" json:"id"`
+	CreatedAt    time.Time       `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+	ProductID    string          `gorm:"type:varchar(32);not null;index" json:"product_id"`
+	VariantID    *string         `gorm:"type:varchar(32);index" json:"variant_id,omitempty"`
+	WarehouseID  *string         `gorm:"type:varchar(32);index" json:"warehouse_id,omitempty"`
+	Quantity     int             `gorm:"type:int;not null" json:"quantity"`
+	BalanceAfter int             `gorm:"type:int;not null" json:"balance_after"`
+	Reason       InventoryReason `gorm:"type:varchar(20);not null" json:"reason"`
+	UserID       *string         `gorm:"type:varchar(32);index" json:"user_id,omitempty"`
+	OrderID      *string         `gorm:"type:varchar(32);index" json:"order_id,omitempty"`
+	Note         string          `gorm:"type:varchar(255)" json:"note,omitempty"`
+}
+
+type InventoryAdjust struct {
+	ProductID   string          `json:"product_id" binding:"required"`
+	VariantID   string          `json:"variant_id,omitempty"`
+	WarehouseID string          `json:"warehouse_id,omitempty"`
+	Quantity    int             `json:"quantity" binding:"required"`
+	Reason      InventoryReason `json:"reason" binding:"required"`
+	Note        string          `json:"note" binding:"max=255"`
+}
+
+func NewInventoryMovement(productID string, quantity int, reason InventoryReason) *InventoryMovement {
+	return &InventoryMovement{
+		ID:        cuid2.Generate(),
+		ProductID: productID,
+		Quantity:  quantity,
+		Reason:    reason,
+	}
+}
@@ -0,0 +1,133 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/nrednav/cuid2"
+)
+
+type OrderStatus string
+
+const (
+	OrderPending   OrderStatus = "pending"
+	OrderPaid      OrderStatus = "paid"
+	OrderShipped   OrderStatus = "shipped"
+	OrderDelivered OrderStatus = "delivered"
+	OrderCancelled OrderStatus = "cancelled"
+	OrderRefunded  OrderStatus = "refunded"
+)
+
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderPending:   {OrderPaid, OrderCancelled},
+	OrderPaid:      {OrderShipped, OrderRefunded},
+	OrderShipped:   {OrderDelivered, OrderRefunded},
+	OrderDelivered: {OrderRefunded},
+	OrderCancelled: {},
+	OrderRefunded:  {},
+}
+
+func (s OrderStatus) Valid() bool {
+	_, ok := orderTransitions[s]
+	return ok
+}
+
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// RestoresStock reports whether reaching this status gives the ordered units
+// back to inventory.
+func (s OrderStatus) RestoresStock() bool {
+	return s == OrderCancelled || s == OrderRefunded
+}
+
+type Order struct {
+	ID        string      `gorm:"type:varchar(32);primaryKey" json:"id"`
+	CreatedAt time.Time   `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+	UpdatedAt *time.Time  `gorm:"type:timestamptz" json:"updated_at,omitempty"`
+	UserID    string      `gorm:"type:varchar(32);not null;index" json:"user_id"`
+	Status    OrderStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	Total     float64     `gorm:"type:numeric(12,2);not null" json:"total"`
+	Items     []OrderItem `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"items,omitempty"`
+}
+
+// OrderItem copies the product data at checkout so later product edits do
+// not rewrite order history.
+type OrderItem struct {
+	ID           string                `gorm:"type:varchar(32);primaryKey" json:"id"`
+	CreatedAt    time.Time             `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+	OrderID      string                `gorm:"type:varchar(32);not null;index" json:"order_id"`
+	ProductID    string                `gorm:"type:varchar(32);not null;index" json:"product_id"`
+	ProductName  string                `gorm:"type:varchar(255);not null" json:"product_name"`
+	VariantID    *string               `gorm:"type:varchar(32);index" json:"variant_id,omitempty"`
+	VariantLabel string                `gorm:"type:varchar(255)" json:"variant_label,omitempty"`
+	Sku          string                `gorm:"type:varchar(100);not null" json:"sku"`
+	UnitPrice    float64               `gorm:"type:numeric(10,2);not null" json:"unit_price"`
+	Quantity     int                   `gorm:"type:int;not null" json:"quantity"`
+	Subtotal     float64               `gorm:"type:numeric(12,2);not null" json:"subtotal"`
+	Allocations  []OrderItemAllocation `gorm:"foreignKey:OrderItemID;constraint:OnDelete:CASCADE" json:"allocations,omitempty"`
+}
+
+// OrderItemAllocation records which warehouse ships part of an order line,
+// so cancellations and refunds put the units back where they came from.
+type OrderItemAllocation struct {
+	ID          string `gorm:"type:varchar(32);primaryKey" json:"id"`
+	OrderItemID string `gorm:"type:varchar(32);not null;index" json:"order_item_id"`
+	WarehouseID string `gorm:"type:varchar(32);not null;index" json:"warehouse_id"`
+	Quantity    int    `gorm:"type:int;not null" json:"quantity"`
+}
+
+// OrderCheckout optionally carries the shipping coordinates used by the
+// closest fulfillment strategy.
+type OrderCheckout struct {
+	Latitude  *float64 `json:"latitude,omitempty" binding:"required_with=Longitude,omitempty,latitude"`
+	Longitude *float64 `json:"longitude,omitempty" binding:"required_with=Latitude,omitempty,longitude"`
+}
+
+type OrderChangeStatus struct {
+	Status OrderStatus `json:"status" binding:"required"`
+}
+
+func NewOrder(userID string) *Order {
+	return &Order{
+		ID:     cuid2.Generate(),
+		UserID: userID,
+		Status: OrderPending,
+	}
+}
+
+func NewOrderItem(orderID string, product *Product, variant *ProductVariant, quantity int) *OrderItem {
+	item := &OrderItem{
+		ID:          cuid2.Generate(),
+		OrderID:     orderID,
+		ProductID:   product.ID,
+		ProductName: product.Name,
+		Sku:         product.Sku,
+		UnitPrice:   product.Price,
+		Quantity:    quantity,
+	}
+
+	if variant != nil {
+		item.VariantID = &variant.ID
+		item.VariantLabel = variant.Label()
+		item.Sku = variant.Sku
+		item.UnitPrice = variant.EffectivePrice(product)
+	}
+
+	item.Subtotal = item.UnitPrice * float64(quantity)
+	return item
+}
+
+func NewOrderItemAllocation(orderItemID string, warehouseID string, quantity int) *OrderItemAllocation {
+	return &OrderItemAllocation{
+		ID:          cuid2.Generate(),
+		OrderItemID: orderItemID,
+		WarehouseID: warehouseID,
+		Quantity:    quantity,
+	}
+}
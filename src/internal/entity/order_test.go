@@ -0,0 +1,51 @@
+package entity
+
+import "testing"
+
+func TestOrderStatusTransitions(t *testing.T) {
+	statuses := []OrderStatus{OrderPending, OrderPaid, OrderShipped, OrderDelivered, OrderCancelled, OrderRefunded}
+
+	allowed := map[OrderStatus]map[OrderStatus]bool{
+		OrderPending:   {OrderPaid: true, OrderCancelled: true},
+		OrderPaid:      {OrderShipped: true, OrderRefunded: true},
+		OrderShipped:   {OrderDelivered: true, OrderRefunded: true},
+		OrderDelivered: {OrderRefunded: true},
+	}
+
+	for _, from := range statuses {
+		if !from.Valid() {
+			t.Errorf("%s should be valid", from)
+		}
+
+		for _, to := range statuses {
+			if got, want := from.CanTransitionTo(to), allowed[from][to]; got != want {
+				t.Errorf("%s -> %s = %t, want %t", from, to, got, want)
+			}
+		}
+	}
+
+	if OrderStatus("lost").Valid() {
+		t.Error("unknown status should not be valid")
+	}
+
+	if OrderStatus("lost").CanTransitionTo(OrderPaid) {
+		t.Error("unknown status should not transition")
+	}
+}
+
+func TestOrderStatusRestoresStock(t *testing.T) {
+	tests := map[OrderStatus]bool{
+		OrderPending:   false,
+		OrderPaid:      false,
+		OrderShipped:   false,
+		OrderDelivered: false,
+		OrderCancelled: true,
+		OrderRefunded:  true,
+	}
+
+	for status, want := range tests {
+		if got := status.RestoresStock(); got != want {
+			t.Errorf("%s.RestoresStock() = %t, want %t", status, got, want)
+		}
+	}
+}
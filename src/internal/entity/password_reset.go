@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/nrednav/cuid2"
+)
+
+type PasswordReset struct {
+	ID        string     `gorm:"type:varchar(32);primaryKey" json:"id"`
+	CreatedAt time.Time  `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+	ExpiresAt time.Time  `gorm:"type:timestamptz;not null" json:"expires_at"`
+	UsedAt    *time.Time `gorm:"type:timestamptz" json:"used_at,omitempty"`
+	UserID    string     `gorm:"type:varchar(32);not null;index" json:"user_id"`
+	TokenHash string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+}
+
+func NewPasswordReset(userID string, tokenHash string, ttl time.Duration) *PasswordReset {
+	return &PasswordReset{
+		ID:        cuid2.Generate(),
+		ExpiresAt: time.Now().Add(ttl),
+		UserID:    userID,
+		TokenHash: tokenHash,
+	}
+}
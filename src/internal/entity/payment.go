@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/gaspartv/api.ecommerce/src/external/payment"
+	"github.com/nrednav/cuid2"
+)
+
+type Payment struct {
+	ID        string         `gorm:"type:varchar(32);primaryKey" json:"id"`
+	CreatedAt time.Time      `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+	UpdatedAt *time.Time     `gorm:"type:timestamptz" json:"updated_at,omitempty"`
+	OrderID   string         `gorm:"type:varchar(32);not null;index" json:"order_id"`
+	Provider  string         `gorm:"type:varchar(50);not null" json:"provider"`
+	Reference string         `gorm:"type:varchar(100);not null;uniqueIndex" json:"reference"`
+	Amount    float64        `gorm:"type:numeric(12,2);not null" json:"amount"`
+	Status    payment.Status `gorm:"type:varchar(20);not null" json:"status"`
+}
+
+type PaymentAuthorize struct {
+	OrderID string `json:"order_id" binding:"required"`
+	Token   string `json:"token"`
+}
+
+type PaymentOrder struct {
+	OrderID string `json:"order_id" binding:"required"`
+}
+
+func NewPayment(orderID string, provider string, amount float64, result *payment.Result) *Payment {
+	return &Payment{
+		ID:        cuid2.Generate(),
+		OrderID:   orderID,
+		Provider:  provider,
+		Reference: result.Reference,
+		Amount:    amount,
+		Status:    result.Status,
+	}
+}
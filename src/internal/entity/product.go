@@ -9,64 +9,87 @@ import (
 )
 
 type Product struct {
-	ID            string         `gorm:"type:varchar(32);primaryKey" json:"id"`
-	CreatedAt     time.Time      `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
-	UpdatedAt     *time.Time     `gorm:"type:timestamptz" json:"updated_at,omitempty"`
-	DisabledAt    *time.Time     `gorm:"type:timestamptz" json:"disabled_at,omitempty"`
-	DeletedAt     gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
-	Name          string         `gorm:"type:varchar(255);not null;uniqueIndex" json:"name"`
-	Description   string         `gorm:"type:varchar(510)" json:"description"`
-	Image         string         `gorm:"type:varchar(255)" json:"image"`
-	Price         float64        `gorm:"type:numeric(10,2);not null" json:"price"`
-	StockQuantity int            `gorm:"type:int;not null" json:"stock_quantity"`
-	CategoryID    string         `gorm:"type:varchar(32);not null;index" json:"category_id"`
-	Sku           string         `gorm:"type:varchar(100);not null;uniqueIndex" json:"sku"`
-	Weight        float64        `gorm:"type:numeric(10,3)" json:"weight"`
-	Dimensions    string         `gorm:"type:varchar(100)" json:"dimensions"`
-	IsFeatured    bool           `gorm:"type:boolean;not null;default:false" json:"is_featured"`
+	ID                string         `gorm:"type:varchar(32);primaryKey" json:"id"`
+	CreatedAt         time.Time      `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+	UpdatedAt         *time.Time     `gorm:"type:timestamptz" json:"updated_at,omitempty"`
+	DisabledAt        *time.Time     `gorm:"type:timestamptz" json:"disabled_at,omitempty"`
+	DeletedAt         gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	Name              string         `gorm:"type:varchar(255);not null;uniqueIndex" json:"name"`
+	Slug              string         `gorm:"type:varchar(255);not null;uniqueIndex:uq_products_slug_not_deleted,where:deleted_at IS NULL" json:"slug"`
+	Description       string         `gorm:"type:varchar(510)" json:"description"`
+	Image             string         `gorm:"type:varchar(255)" json:"image"`
+	ImageVariants     ImageVariants  `gorm:"type:jsonb" json:"image_variants,omitempty"`
+	Price             float64        `gorm:"type:numeric(10,2);not null" json:"price"`
+	StockQuantity     int            `gorm:"type:int;not null" json:"stock_quantity"`
+	CategoryID        string         `gorm:"type:varchar(32);not null;index" json:"category_id"`
+	Sku               string         `gorm:"type:varchar(100);not null;uniqueIndex" json:"sku"`
+	Weight            float64        `gorm:"type:numeric(10,3)" json:"weight"`
+	Dimensions        string         `gorm:"type:varchar(100)" json:"dimensions"`
+	IsFeatured        bool           `gorm:"type:boolean;not null;default:false" json:"is_featured"`
+	ReorderPoint      int            `gorm:"type:int;not null;default:0" json:"reorder_point"`
+	ReorderQuantity   int            `gorm:"type:int;not null;default:0" json:"reorder_quantity"`
+	LowStockAlertedAt *time.Time     `gorm:"type:timestamptz" json:"low_stock_alerted_at,omitempty"`
 }
 
 type ProductWithCategory struct {
 	Product
-	CategoryName string `json:"category_name"`
+	CategoryName      string              `json:"category_name"`
+	AvailableQuantity int                 `json:"available_quantity"`
+	Options           []ProductOptionType `gorm:"-" json:"options"`
+	Variants          []ProductVariant    `gorm:"-" json:"variants"`
+}
+
+// ProductDetail is the single-product view with its gallery and products
+// from the same category.
+type ProductDetail struct {
+	ProductWithCategory
+	Images  []ProductImage        `json:"images"`
+	InStock bool                  `json:"in_stock"`
+	Related []ProductWithCategory `json:"related"`
 }
 
 type ProductCreate struct {
-	Name          string  `json:"name" binding:"required"`
-	Description   string  `json:"description" binding:"required"`
-	Price         float64 `json:"price" binding:"required"`
-	StockQuantity int     `json:"stock_quantity" binding:"required"`
-	CategoryID    string  `json:"category_id" binding:"required"`
-	Sku           string  `json:"sku" binding:"required"`
-	Weight        float64 `json:"weight,omitempty"`
-	Dimensions    string  `json:"dimensions,omitempty"`
-	IsFeatured    bool    `json:"is_featured,omitempty"`
+	Name            string  `json:"name" binding:"required"`
+	Description     string  `json:"description" binding:"required"`
+	Price           float64 `json:"price" binding:"required"`
+	StockQuantity   int     `json:"stock_quantity" binding:"required"`
+	CategoryID      string  `json:"category_id" binding:"required"`
+	Sku             string  `json:"sku" binding:"required"`
+	Weight          float64 `json:"weight,omitempty"`
+	Dimensions      string  `json:"dimensions,omitempty"`
+	IsFeatured      bool    `json:"is_featured,omitempty"`
+	ReorderPoint    int     `json:"reorder_point,omitempty" binding:"min=0"`
+	ReorderQuantity int     `json:"reorder_quantity,omitempty" binding:"min=0"`
 }
 
 type ProductEdit struct {
-	Name          *string  `json:"name,omitempty"`
-	Description   *string  `json:"description,omitempty"`
-	Price         *float64 `json:"price,omitempty"`
-	StockQuantity *int     `json:"stock_quantity,omitempty"`
-	CategoryID    *string  `json:"category_id,omitempty"`
-	Sku           *string  `json:"sku,omitempty"`
-	Weight        *float64 `json:"weight,omitempty"`
-	Dimensions    *string  `json:"dimensions,omitempty"`
-	IsFeatured    *bool    `json:"is_featured,omitempty"`
+	Name            *string  `json:"name,omitempty"`
+	Description     *string  `json:"description,omitempty"`
+	Price           *float64 `json:"price,omitempty"`
+	StockQuantity   *int     `json:"stock_quantity,omitempty"`
+	CategoryID      *string  `json:"category_id,omitempty"`
+	Sku             *string  `json:"sku,omitempty"`
+	Weight          *float64 `json:"weight,omitempty"`
+	Dimensions      *string  `json:"dimensions,omitempty"`
+	IsFeatured      *bool    `json:"is_featured,omitempty"`
+	ReorderPoint    *int     `json:"reorder_point,omitempty" binding:"omitempty,min=0"`
+	ReorderQuantity *int     `json:"reorder_quantity,omitempty" binding:"omitempty,min=0"`
 }
 
 func NewProduct(create ProductCreate, env *config.Env) *Product {
 	return &Product{
-		ID:            cuid2.Generate(),
-		Name:          create.Name,
-		Description:   create.Description,
-		Image:         env.IMAGE_CATEGORY_DEFAULT_URL,
-		Price:         create.Price,
-		StockQuantity: create.StockQuantity,
-		CategoryID:    create.CategoryID,
-		Sku:           create.Sku,
-		Weight:        create.Weight,
-		Dimensions:    create.Dimensions,
-		IsFeatured:    create.IsFeatured,
+		ID:              cuid2.Generate(),
+		Name:            create.Name,
+		Description:     create.Description,
+		Image:           env.IMAGE_CATEGORY_DEFAULT_URL,
+		Price:           create.Price,
+		StockQuantity:   create.StockQuantity,
+		CategoryID:      create.CategoryID,
+		Sku:             create.Sku,
+		Weight:          create.Weight,
+		Dimensions:      create.Dimensions,
+		IsFeatured:      create.IsFeatured,
+		ReorderPoint:    create.ReorderPoint,
+		ReorderQuantity: create.ReorderQuantity,
 	}
 }
@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/nrednav/cuid2"
+)
+
+// ProductImage is one picture of a product gallery. URL is the large
+// rendition, Key its storage key, and Variants holds every rendition.
+// Identical uploads share objects, so they are only removed once no row
+// uses them. The primary image is mirrored into Product.Image.
+type ProductImage struct {
+	ID        string        `gorm:"type:varchar(32);primaryKey" json:"id"`
+	CreatedAt time.Time     `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+	ProductID string        `gorm:"type:varchar(32);not null;index" json:"product_id"`
+	URL       string        `gorm:"type:varchar(255);not null" json:"url"`
+	Variants  ImageVariants `gorm:"type:jsonb" json:"variants"`
+	Key       string        `gorm:"type:varchar(255);not null" json:"-"`
+	Position  int           `gorm:"type:int;not null;default:0" json:"position"`
+	AltText   string        `gorm:"type:varchar(255)" json:"alt_text"`
+	IsPrimary bool          `gorm:"type:boolean;not null;default:false" json:"is_primary"`
+}
+
+type ProductImageReorder struct {
+	ProductID string   `json:"product_id" binding:"required"`
+	ImageIDs  []string `json:"image_ids" binding:"required,min=1"`
+}
+
+type ProductImageEdit struct {
+	AltText   *string `json:"alt_text,omitempty" binding:"omitempty,max=255"`
+	IsPrimary *bool   `json:"is_primary,omitempty"`
+}
+
+// NewProductImage creates a gallery entry; the caller fills URL, Variants
+// and Key once the renditions are stored.
+func NewProductImage(productID string, position int, altText string) *ProductImage {
+	return &ProductImage{
+		ID:        cuid2.Generate(),
+		ProductID: productID,
+		Position:  position,
+		AltText:   altText,
+	}
+}
@@ -0,0 +1,86 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nrednav/cuid2"
+)
+
+const (
+	ProductImportPending   = "pending"
+	ProductImportRunning   = "running"
+	ProductImportValidated = "validated"
+	ProductImportCompleted = "completed"
+	ProductImportFailed    = "failed"
+)
+
+// ProductImport tracks a spreadsheet import running in the background.
+// The file is validated as a whole first; when any row is invalid nothing
+// is written and Errors lists every problem by line.
+type ProductImport struct {
+	ID            string              `gorm:"type:varchar(32);primaryKey" json:"id"`
+	CreatedAt     time.Time           `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+	UpdatedAt     *time.Time          `gorm:"type:timestamptz" json:"updated_at,omitempty"`
+	FinishedAt    *time.Time          `gorm:"type:timestamptz" json:"finished_at,omitempty"`
+	UserID        *string             `gorm:"type:varchar(32);index" json:"user_id,omitempty"`
+	Filename      string              `gorm:"type:varchar(255);not null" json:"filename"`
+	ValidateOnly  bool                `gorm:"type:boolean;not null;default:false" json:"validate_only"`
+	Status        string              `gorm:"type:varchar(20);not null" json:"status"`
+	TotalRows     int                 `gorm:"type:int;not null;default:0" json:"total_rows"`
+	ProcessedRows int                 `gorm:"type:int;not null;default:0" json:"processed_rows"`
+	CreatedCount  int                 `gorm:"type:int;not null;default:0" json:"created_count"`
+	UpdatedCount  int                 `gorm:"type:int;not null;default:0" json:"updated_count"`
+	Errors        ProductImportErrors `gorm:"type:jsonb" json:"errors"`
+	Message       string              `gorm:"type:varchar(510)" json:"message,omitempty"`
+}
+
+// ProductImportError is a problem with one line of the file; line 1 is the
+// header.
+type ProductImportError struct {
+	Line    int    `json:"line"`
+	Sku     string `json:"sku,omitempty"`
+	Message string `json:"message"`
+}
+
+type ProductImportErrors []ProductImportError
+
+func (e ProductImportErrors) Value() (driver.Value, error) {
+	if e == nil {
+		return "[]", nil
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+func (e *ProductImportErrors) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case nil:
+		*e = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(data, e)
+	case string:
+		return json.Unmarshal([]byte(data), e)
+	default:
+		return fmt.Errorf("cannot scan %T into ProductImportErrors", src)
+	}
+}
+
+func NewProductImport(userID *string, filename string, validateOnly bool) *ProductImport {
+	return &ProductImport{
+		ID:           cuid2.Generate(),
+		UserID:       userID,
+		Filename:     filename,
+		ValidateOnly: validateOnly,
+		Status:       ProductImportPending,
+		Errors:       ProductImportErrors{},
+	}
+}
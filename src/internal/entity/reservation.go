@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/nrednav/cuid2"
+)
+
+// StockReservation is a soft hold on product or, with VariantID, variant
+// stock taken when checkout starts. It stops counting once it expires, is
+// released or is consumed by the order it was taken for.
+type StockReservation struct {
+	ID         string     `gorm:"type:varchar(32);primaryKey" json:"id"`
+	CreatedAt  time.Time  `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+	ExpiresAt  time.Time  `gorm:"type:timestamptz;not null;index" json:"expires_at"`
+	ReleasedAt *time.Time `gorm:"type:timestamptz" json:"released_at,omitempty"`
+	ConsumedAt *time.Time `gorm:"type:timestamptz" json:"consumed_at,omitempty"`
+	ProductID  string     `gorm:"type:varchar(32);not null;index" json:"product_id"`
+	VariantID  *string    `gorm:"type:varchar(32);index" json:"variant_id,omitempty"`
+	CartID     string     `gorm:"type:varchar(32);not null;index" json:"cart_id"`
+	Quantity   int        `gorm:"type:int;not null" json:"quantity"`
+}
+
+func NewStockReservation(cartID string, productID string, variantID *string, quantity int, ttl time.Duration) *StockReservation {
+	return &StockReservation{
+		ID:        cuid2.Generate(),
+		ExpiresAt: time.Now().Add(ttl),
+		ProductID: productID,
+		VariantID: variantID,
+		CartID:    cartID,
+		Quantity:  quantity,
+	}
+}
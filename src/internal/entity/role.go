@@ -0,0 +1,87 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/nrednav/cuid2"
+)
+
+const (
+	RoleAdmin    = "admin"
+	RoleStaff    = "staff"
+	RoleCustomer = "customer"
+)
+
+const (
+	PermissionCatalogRead   = "catalog:read"
+	PermissionCatalogWrite  = "catalog:write"
+	PermissionCatalogDelete = "catalog:delete"
+	PermissionUsersManage   = "users:manage"
+	PermissionOrdersManage  = "orders:manage"
+)
+
+// DefaultRolePermissions is seeded on startup. Permissions granted later
+// through the database are kept; missing defaults are added back.
+var DefaultRolePermissions = map[string][]string{
+	RoleAdmin: {
+		PermissionCatalogRead,
+		PermissionCatalogWrite,
+		PermissionCatalogDelete,
+		PermissionUsersManage,
+		PermissionOrdersManage,
+	},
+	RoleStaff: {
+		PermissionCatalogRead,
+		PermissionCatalogWrite,
+		PermissionOrdersManage,
+	},
+	RoleCustomer: {
+		PermissionCatalogRead,
+	},
+}
+
+type Role struct {
+	ID          string       `gorm:"type:varchar(32);primaryKey" json:"id"`
+	CreatedAt   time.Time    `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+	UpdatedAt   *time.Time   `gorm:"type:timestamptz" json:"updated_at,omitempty"`
+	Name        string       `gorm:"type:varchar(50);not null;uniqueIndex" json:"name"`
+	Permissions []Permission `gorm:"many2many:role_permissions" json:"permissions,omitempty"`
+}
+
+type Permission struct {
+	ID        string    `gorm:"type:varchar(32);primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+	Name      string    `gorm:"type:varchar(100);not null;uniqueIndex" json:"name"`
+}
+
+type UserChangeRole struct {
+	Role string `json:"role" binding:"required"`
+}
+
+func NewRole(name string) *Role {
+	return &Role{
+		ID:   cuid2.Generate(),
+		Name: name,
+	}
+}
+
+func NewPermission(name string) *Permission {
+	return &Permission{
+		ID:   cuid2.Generate(),
+		Name: name,
+	}
+}
+
+func (r *Role) HasPermission(permission string) bool {
+	if r == nil {
+		return false
+	}
+
+	for _, p := range r.Permissions {
+		if p.Name == permission {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/nrednav/cuid2"
+)
+
+type Session struct {
+	ID        string     `gorm:"type:varchar(32);primaryKey" json:"id"`
+	CreatedAt time.Time  `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+	ExpiresAt time.Time  `gorm:"type:timestamptz;not null" json:"expires_at"`
+	RevokedAt *time.Time `gorm:"type:timestamptz" json:"revoked_at,omitempty"`
+	UserID    string     `gorm:"type:varchar(32);not null;index" json:"user_id"`
+	TokenHash string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	UserAgent string     `gorm:"type:varchar(255)" json:"user_agent"`
+	IP        string     `gorm:"type:varchar(64)" json:"ip"`
+}
+
+type SessionTokens struct {
+	AccessToken      string    `json:"access_token"`
+	AccessExpiresAt  time.Time `json:"access_expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	TokenType        string    `json:"token_type"`
+}
+
+type UserLogin struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+type SessionRefresh struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+func NewSession(userID string, tokenHash string, ttl time.Duration) *Session {
+	return &Session{
+		ID:        cuid2.Generate(),
+		ExpiresAt: time.Now().Add(ttl),
+		UserID:    userID,
+		TokenHash: tokenHash,
+	}
+}
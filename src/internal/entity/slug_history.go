@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/nrednav/cuid2"
+)
+
+const (
+	SlugEntityProduct  = "product"
+	SlugEntityCategory = "category"
+)
+
+// SlugHistory keeps slugs a product or category used to have, so old links
+// can be redirected to the current one.
+type SlugHistory struct {
+	ID         string    `gorm:"type:varchar(32);primaryKey" json:"id"`
+	CreatedAt  time.Time `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+	EntityType string    `gorm:"type:varchar(20);not null;uniqueIndex:uq_slug_histories_type_slug" json:"entity_type"`
+	EntityID   string    `gorm:"type:varchar(32);not null;index" json:"entity_id"`
+	Slug       string    `gorm:"type:varchar(255);not null;uniqueIndex:uq_slug_histories_type_slug" json:"slug"`
+}
+
+func NewSlugHistory(entityType string, entityID string, slug string) *SlugHistory {
+	return &SlugHistory{
+		ID:         cuid2.Generate(),
+		EntityType: entityType,
+		EntityID:   entityID,
+		Slug:       slug,
+	}
+}
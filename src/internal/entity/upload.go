@@ -0,0 +1,52 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/nrednav/cuid2"
+)
+
+const (
+	UploadTargetCategory = "category"
+	UploadTargetProduct  = "product"
+	UploadTargetUser     = "user"
+)
+
+// Upload is a presigned direct upload waiting for the client to PUT the
+// file and call complete. The size and content type are the ones the URL
+// was signed for and are checked again against the stored object.
+type Upload struct {
+	ID          string     `gorm:"type:varchar(32);primaryKey" json:"id"`
+	CreatedAt   time.Time  `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+	ExpiresAt   time.Time  `gorm:"type:timestamptz;not null" json:"expires_at"`
+	CompletedAt *time.Time `gorm:"type:timestamptz" json:"completed_at,omitempty"`
+	UserID      string     `gorm:"type:varchar(32);not null;index" json:"user_id"`
+	Key         string     `gorm:"type:varchar(255);not null" json:"key"`
+	ContentType string     `gorm:"type:varchar(100);not null" json:"content_type"`
+	Size        int64      `gorm:"type:bigint;not null" json:"size"`
+	Target      string     `gorm:"type:varchar(20);not null" json:"target"`
+	TargetID    string     `gorm:"type:varchar(32);not null" json:"target_id"`
+}
+
+type UploadPresign struct {
+	Target      string `json:"target" binding:"required,oneof=category product user"`
+	TargetID    string `json:"target_id"`
+	ContentType string `json:"content_type" binding:"required,oneof=image/jpeg image/png image/gif image/webp"`
+	Size        int64  `json:"size" binding:"required,min=1"`
+}
+
+type UploadComplete struct {
+	UploadID string `json:"upload_id" binding:"required"`
+}
+
+func NewUpload(userID string, body UploadPresign, ttl time.Duration) *Upload {
+	return &Upload{
+		ID:          cuid2.Generate(),
+		ExpiresAt:   time.Now().Add(ttl),
+		UserID:      userID,
+		ContentType: body.ContentType,
+		Size:        body.Size,
+		Target:      body.Target,
+		TargetID:    body.TargetID,
+	}
+}
@@ -1,6 +1,7 @@
 package entity
 
 import (
+	"strings"
 	"time"
 
 	"github.com/gaspartv/api.ecommerce/src/config"
@@ -17,9 +18,11 @@ type User struct {
 	DeletedAt       gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 	Name            string         `gorm:"type:varchar(255);not null;uniqueIndex" json:"name"`
 	Image           string         `gorm:"type:varchar(255)" json:"image"`
-	Email           string         `gorm:"type:varchar(255);not null;uniqueIndex" json:"email"`
+	Email           string         `gorm:"type:varchar(255);not null;uniqueIndex:uq_users_email_not_deleted,where:deleted_at IS NULL" json:"email"`
 	EmailVerifiedAt *time.Time     `gorm:"type:timestamptz" json:"email_verified_at,omitempty"`
 	PasswordHash    string         `gorm:"type:varchar(255);not null" json:"-"`
+	RoleID          string         `gorm:"type:varchar(32);index" json:"role_id"`
+	Role            *Role          `gorm:"foreignKey:RoleID" json:"role,omitempty"`
 }
 
 type UserCreate struct {
@@ -28,8 +31,42 @@ type UserCreate struct {
 	PasswordHash string `json:"password" binding:"required"`
 }
 
+type UserEdit struct {
+	Name  *string `json:"name,omitempty"`
+	Email *string `json:"email,omitempty" binding:"omitempty,email"`
+}
+
+type UserResendVerification struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type UserForgotPassword struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type UserResetPassword struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type UserChangePassword struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+const PasswordCost = 10
+
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), PasswordCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
 func NewUser(user UserCreate, env *config.Env) (*User, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(user.PasswordHash), 10)
+	hash, err := HashPassword(user.PasswordHash)
 	if err != nil {
 		return nil, err
 	}
@@ -38,7 +75,13 @@ func NewUser(user UserCreate, env *config.Env) (*User, error) {
 		ID:           cuid2.Generate(),
 		Name:         user.Name,
 		Image:        env.IMAGE_CATEGORY_DEFAULT_URL,
-		Email:        user.Email,
-		PasswordHash: string(hash),
+		Email:        strings.ToLower(strings.TrimSpace(user.Email)),
+		PasswordHash: hash,
 	}, nil
 }
+
+// HasPermission is false for a nil user, so anonymous requests can be
+// checked the same way as signed-in ones.
+func (u *User) HasPermission(permission string) bool {
+	return u != nil && u.Role.HasPermission(permission)
+}
@@ -0,0 +1,125 @@
+package entity
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nrednav/cuid2"
+	"gorm.io/gorm"
+)
+
+// ProductOptionType is an axis a product varies on, such as size or color.
+type ProductOptionType struct {
+	ID        string               `gorm:"type:varchar(32);primaryKey" json:"id"`
+	CreatedAt time.Time            `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+	ProductID string               `gorm:"type:varchar(32);not null;uniqueIndex:uq_product_option_types_product_name" json:"product_id"`
+	Name      string               `gorm:"type:varchar(100);not null;uniqueIndex:uq_product_option_types_product_name" json:"name"`
+	Position  int                  `gorm:"type:int;not null;default:0" json:"position"`
+	Values    []ProductOptionValue `gorm:"foreignKey:OptionTypeID;constraint:OnDelete:CASCADE" json:"values"`
+}
+
+type ProductOptionValue struct {
+	ID           string             `gorm:"type:varchar(32);primaryKey" json:"id"`
+	OptionTypeID string             `gorm:"type:varchar(32);not null;uniqueIndex:uq_product_option_values_type_value" json:"option_type_id"`
+	Value        string             `gorm:"type:varchar(100);not null;uniqueIndex:uq_product_option_values_type_value" json:"value"`
+	Position     int                `gorm:"type:int;not null;default:0" json:"position"`
+	OptionType   *ProductOptionType `gorm:"foreignKey:OptionTypeID" json:"option_type,omitempty"`
+}
+
+// ProductVariant is a sellable combination of option values. Price is an
+// override and the parent price applies when it is nil. Variant stock lives
+// on the variant row, is moved through the inventory ledger without a
+// warehouse and is checked against the parent's reorder point.
+type ProductVariant struct {
+	ID                string               `gorm:"type:varchar(32);primaryKey" json:"id"`
+	CreatedAt         time.Time            `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+	UpdatedAt         *time.Time           `gorm:"type:timestamptz" json:"updated_at,omitempty"`
+	DisabledAt        *time.Time           `gorm:"type:timestamptz" json:"disabled_at,omitempty"`
+	DeletedAt         gorm.DeletedAt       `gorm:"index" json:"deleted_at,omitempty"`
+	ProductID         string               `gorm:"type:varchar(32);not null;index" json:"product_id"`
+	Sku               string               `gorm:"type:varchar(100);not null;uniqueIndex:uq_product_variants_sku_not_deleted,where:deleted_at IS NULL" json:"sku"`
+	Price             *float64             `gorm:"type:numeric(10,2)" json:"price,omitempty"`
+	StockQuantity     int                  `gorm:"type:int;not null;default:0" json:"stock_quantity"`
+	LowStockAlertedAt *time.Time           `gorm:"type:timestamptz" json:"low_stock_alerted_at,omitempty"`
+	Image             string               `gorm:"type:varchar(255)" json:"image"`
+	OptionValues      []ProductOptionValue `gorm:"many2many:product_variant_option_values;joinForeignKey:VariantID;joinReferences:OptionValueID" json:"option_values"`
+}
+
+type ProductOptionCreate struct {
+	ProductID string   `json:"product_id" binding:"required"`
+	Name      string   `json:"name" binding:"required,max=100"`
+	Position  int      `json:"position,omitempty"`
+	Values    []string `json:"values" binding:"required,min=1,dive,required,max=100"`
+}
+
+type ProductVariantCreate struct {
+	ProductID      string   `json:"product_id" binding:"required"`
+	Sku            string   `json:"sku" binding:"required"`
+	Price          *float64 `json:"price,omitempty" binding:"omitempty,gt=0"`
+	StockQuantity  int      `json:"stock_quantity" binding:"min=0"`
+	OptionValueIDs []string `json:"option_value_ids" binding:"required,min=1"`
+}
+
+type ProductVariantEdit struct {
+	Sku           *string  `json:"sku,omitempty"`
+	Price         *float64 `json:"price,omitempty" binding:"omitempty,gt=0"`
+	StockQuantity *int     `json:"stock_quantity,omitempty" binding:"omitempty,min=0"`
+}
+
+func NewProductOptionType(create ProductOptionCreate) *ProductOptionType {
+	optionType := &ProductOptionType{
+		ID:        cuid2.Generate(),
+		ProductID: create.ProductID,
+		Name:      create.Name,
+		Position:  create.Position,
+	}
+
+	for position, value := range create.Values {
+		optionType.Values = append(optionType.Values, ProductOptionValue{
+			ID:           cuid2.Generate(),
+			OptionTypeID: optionType.ID,
+			Value:        value,
+			Position:     position,
+		})
+	}
+
+	return optionType
+}
+
+func NewProductVariant(create ProductVariantCreate, image string) *ProductVariant {
+	return &ProductVariant{
+		ID:            cuid2.Generate(),
+		ProductID:     create.ProductID,
+		Sku:           create.Sku,
+		Price:         create.Price,
+		StockQuantity: create.StockQuantity,
+		Image:         image,
+	}
+}
+
+// EffectivePrice is the variant override or, without one, the parent price.
+func (v *ProductVariant) EffectivePrice(product *Product) float64 {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return product.Price
+}
+
+// Label joins the option values in option order, e.g. "M / Red".
+func (v *ProductVariant) Label() string {
+	values := append([]ProductOptionValue(nil), v.OptionValues...)
+	sort.SliceStable(values, func(i, j int) bool {
+		if values[i].OptionType != nil && values[j].OptionType != nil {
+			return values[i].OptionType.Position < values[j].OptionType.Position
+		}
+		return false
+	})
+
+	labels := make([]string, 0, len(values))
+	for _, value := range values {
+		labels = append(labels, value.Value)
+	}
+
+	return strings.Join(labels, " / ")
+}
@@ -0,0 +1,66 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/nrednav/cuid2"
+	"gorm.io/gorm"
+)
+
+type Warehouse struct {
+	ID         string         `gorm:"type:varchar(32);primaryKey" json:"id"`
+	CreatedAt  time.Time      `gorm:"type:timestamptz;not null;default:now()" json:"created_at"`
+	UpdatedAt  *time.Time     `gorm:"type:timestamptz" json:"updated_at,omitempty"`
+	DisabledAt *time.Time     `gorm:"type:timestamptz" json:"disabled_at,omitempty"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	Name       string         `gorm:"type:varchar(255);not null" json:"name"`
+	Code       string         `gorm:"type:varchar(50);not null;uniqueIndex:uq_warehouses_code_not_deleted,where:deleted_at IS NULL" json:"code"`
+	Latitude   *float64       `gorm:"type:numeric(9,6)" json:"latitude,omitempty"`
+	Longitude  *float64       `gorm:"type:numeric(9,6)" json:"longitude,omitempty"`
+	Priority   int            `gorm:"type:int;not null;default:0" json:"priority"`
+}
+
+// WarehouseStock is the on-hand quantity of a product in one warehouse.
+// products.stock_quantity is kept equal to the sum of these rows.
+type WarehouseStock struct {
+	WarehouseID string     `gorm:"type:varchar(32);primaryKey" json:"warehouse_id"`
+	ProductID   string     `gorm:"type:varchar(32);primaryKey;index" json:"product_id"`
+	Quantity    int        `gorm:"type:int;not null;default:0" json:"quantity"`
+	UpdatedAt   *time.Time `gorm:"type:timestamptz" json:"updated_at,omitempty"`
+	Warehouse   *Warehouse `gorm:"foreignKey:WarehouseID" json:"warehouse,omitempty"`
+}
+
+type WarehouseCreate struct {
+	Name      string   `json:"name" binding:"required"`
+	Code      string   `json:"code" binding:"required"`
+	Latitude  *float64 `json:"latitude,omitempty" binding:"omitempty,latitude"`
+	Longitude *float64 `json:"longitude,omitempty" binding:"omitempty,longitude"`
+	Priority  int      `json:"priority,omitempty"`
+}
+
+type WarehouseEdit struct {
+	Name      *string  `json:"name,omitempty"`
+	Code      *string  `json:"code,omitempty"`
+	Latitude  *float64 `json:"latitude,omitempty" binding:"omitempty,latitude"`
+	Longitude *float64 `json:"longitude,omitempty" binding:"omitempty,longitude"`
+	Priority  *int     `json:"priority,omitempty"`
+}
+
+type WarehouseTransfer struct {
+	ProductID       string `json:"product_id" binding:"required"`
+	FromWarehouseID string `json:"from_warehouse_id" binding:"required"`
+	ToWarehouseID   string `json:"to_warehouse_id" binding:"required,nefield=FromWarehouseID"`
+	Quantity        int    `json:"quantity" binding:"required,min=1"`
+	Note            string `json:"note" binding:"max=255"`
+}
+
+func NewWarehouse(create WarehouseCreate) *Warehouse {
+	return &Warehouse{
+		ID:        cuid2.Generate(),
+		Name:      create.Name,
+		Code:      create.Code,
+		Latitude:  create.Latitude,
+		Longitude: create.Longitude,
+		Priority:  create.Priority,
+	}
+}
@@ -0,0 +1,477 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gaspartv/api.ecommerce/src/config"
+	"github.com/gaspartv/api.ecommerce/src/internal/auth"
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"github.com/gaspartv/api.ecommerce/src/internal/inventory"
+	"github.com/gaspartv/api.ecommerce/src/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CartTokenHeader identifies a guest cart. It is returned when a guest cart
+// is created and must be sent back on later cart requests and on login.
+const CartTokenHeader = "X-Cart-Token"
+
+var (
+	errCartNotFound       = errors.New("cart not found")
+	errProductUnavailable = errors.New("product unavailable")
+	errVariantNotFound    = errors.New("variant not found")
+	errVariantRequired    = errors.New("variant required")
+)
+
+type CartHandle struct {
+	db  *gorm.DB
+	env *config.Env
+}
+
+func NewCartHandler(db *gorm.DB, env *config.Env) *CartHandle {
+	return &CartHandle{
+		db:  db,
+		env: env,
+	}
+}
+
+func (h *CartHandle) Current(ctx *gin.Context) {
+	cart, err := h.findCart(ctx)
+	if err != nil {
+		if errors.Is(err, errCartNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Cart not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respondCart(ctx, http.StatusOK, cart.ID)
+}
+
+func (h *CartHandle) AddItem(ctx *gin.Context) {
+	var body entity.CartAddItem
+
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	cart, err := h.findOrCreateCart(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	product, err := findAvailableProduct(h.db, body.ProductID)
+	if err != nil {
+		respondProductError(ctx, err)
+		return
+	}
+
+	variant, err := findAvailableVariant(h.db, product.ID, body.VariantID)
+	if err != nil {
+		respondProductError(ctx, err)
+		return
+	}
+
+	// The cart row is locked so concurrent adds of the same line neither
+	// insert it twice nor overwrite each other's quantity.
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", cart.ID).First(&entity.Cart{}).Error; err != nil {
+			return err
+		}
+
+		var item entity.CartItem
+		err := findCartLine(tx, cart.ID, product.ID, body.VariantID).First(&item).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		exists := err == nil
+		quantity := body.Quantity
+		if exists {
+			quantity += item.Quantity
+		}
+
+		available, err := availableStock(tx, product.ID, variant, cart.ID)
+		if err != nil {
+			return err
+		}
+
+		if quantity > available {
+			return &requestError{http.StatusConflict, "Insufficient stock"}
+		}
+
+		if exists {
+			return tx.Model(&item).Update("quantity", quantity).Error
+		}
+		return tx.Create(entity.NewCartItem(cart.ID, product, variant, quantity)).Error
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	h.respondCart(ctx, http.StatusOK, cart.ID)
+}
+
+func (h *CartHandle) UpdateItem(ctx *gin.Context) {
+	idParam := ctx.Query("id")
+	if idParam == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	var body entity.CartUpdateItem
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	cart, err := h.findCart(ctx)
+	if err != nil {
+		if errors.Is(err, errCartNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Cart not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var item entity.CartItem
+	if err := h.db.Where("id = ? AND cart_id = ?", idParam, cart.ID).First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Cart item not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	product, err := findAvailableProduct(h.db, item.ProductID)
+	if err != nil {
+		respondProductError(ctx, err)
+		return
+	}
+
+	variant, err := findAvailableVariant(h.db, product.ID, item.VariantID)
+	if err != nil {
+		respondProductError(ctx, err)
+		return
+	}
+
+	available, err := availableStock(h.db, product.ID, variant, cart.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if body.Quantity > available {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Insufficient stock"})
+		return
+	}
+
+	if err := h.db.Model(&item).Update("quantity", body.Quantity).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respondCart(ctx, http.StatusOK, cart.ID)
+}
+
+func (h *CartHandle) RemoveItem(ctx *gin.Context) {
+	idParam := ctx.Query("id")
+	if idParam == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	cart, err := h.findCart(ctx)
+	if err != nil {
+		if errors.Is(err, errCartNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Cart not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := h.db.Where("id = ? AND cart_id = ?", idParam, cart.ID).Delete(&entity.CartItem{})
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Cart item not found"})
+		return
+	}
+
+	h.respondCart(ctx, http.StatusOK, cart.ID)
+}
+
+func (h *CartHandle) Clear(ctx *gin.Context) {
+	cart, err := h.findCart(ctx)
+	if err != nil {
+		if errors.Is(err, errCartNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Cart not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := inventory.ReleaseCart(tx, cart.ID); err != nil {
+			return err
+		}
+		return tx.Where("cart_id = ?", cart.ID).Delete(&entity.CartItem{}).Error
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Cart cleared successfully"})
+}
+
+func (h *CartHandle) respondCart(ctx *gin.Context, status int, cartID string) {
+	var cart entity.Cart
+	if err := h.db.
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		Preload("Items.Product").
+		Preload("Items.Variant.OptionValues").
+		Where("id = ?", cartID).
+		First(&cart).Error; err != nil {
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(status, gin.H{
+		"data":  cart,
+		"total": cart.Total(),
+	})
+}
+
+func (h *CartHandle) findCart(ctx *gin.Context) (*entity.Cart, error) {
+	var cart entity.Cart
+	var err error
+
+	if user := middleware.CurrentUser(ctx); user != nil {
+		err = h.db.Where("user_id = ?", user.ID).First(&cart).Error
+	} else {
+		token := ctx.GetHeader(CartTokenHeader)
+		if token == "" {
+			return nil, errCartNotFound
+		}
+		err = h.db.Where("token_hash = ? AND user_id IS NULL", auth.HashOpaqueToken(token)).First(&cart).Error
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errCartNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &cart, nil
+}
+
+func (h *CartHandle) findOrCreateCart(ctx *gin.Context) (*entity.Cart, error) {
+	cart, err := h.findCart(ctx)
+	if err == nil || !errors.Is(err, errCartNotFound) {
+		return cart, err
+	}
+
+	if user := middleware.CurrentUser(ctx); user != nil {
+		cart = entity.NewUserCart(user.ID)
+		return cart, h.db.Create(cart).Error
+	}
+
+	token, tokenHash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	cart = entity.NewGuestCart(tokenHash)
+	if err := h.db.Create(cart).Error; err != nil {
+		return nil, err
+	}
+
+	ctx.Header(CartTokenHeader, token)
+	return cart, nil
+}
+
+func findAvailableProduct(tx *gorm.DB, id string) (*entity.Product, error) {
+	var product entity.Product
+	if err := tx.Where("id = ? AND deleted_at IS NULL", id).First(&product).Error; err != nil {
+		return nil, err
+	}
+
+	if product.DisabledAt != nil {
+		return nil, errProductUnavailable
+	}
+
+	return &product, nil
+}
+
+// findAvailableVariant loads the picked variant of a product. Products that
+// have live variants can only be sold through one of them.
+func findAvailableVariant(tx *gorm.DB, productID string, variantID *string) (*entity.ProductVariant, error) {
+	if variantID == nil {
+		var count int64
+		if err := tx.Model(&entity.ProductVariant{}).
+			Where("product_id = ? AND deleted_at IS NULL AND disabled_at IS NULL", productID).
+			Count(&count).Error; err != nil {
+			return nil, err
+		}
+
+		if count > 0 {
+			return nil, errVariantRequired
+		}
+		return nil, nil
+	}
+
+	var variant entity.ProductVariant
+	err := tx.Where("id = ? AND product_id = ? AND deleted_at IS NULL", *variantID, productID).First(&variant).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errVariantNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if variant.DisabledAt != nil {
+		return nil, errProductUnavailable
+	}
+
+	return &variant, nil
+}
+
+func findCartLine(tx *gorm.DB, cartID string, productID string, variantID *string) *gorm.DB {
+	query := tx.Where("cart_id = ? AND product_id = ?", cartID, productID)
+	if variantID == nil {
+		return query.Where("variant_id IS NULL")
+	}
+	return query.Where("variant_id = ?", *variantID)
+}
+
+// availableStock is the sellable quantity of a cart line: the variant or
+// product stock net of other carts' holds.
+func availableStock(tx *gorm.DB, productID string, variant *entity.ProductVariant, cartID string) (int, error) {
+	if variant != nil {
+		return inventory.AvailableVariant(tx, variant.ID, cartID)
+	}
+	return inventory.Available(tx, productID, cartID)
+}
+
+func respondProductError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+	case errors.Is(err, errVariantNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Variant not found"})
+	case errors.Is(err, errVariantRequired):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Pick a variant of this product"})
+	case errors.Is(err, errProductUnavailable):
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Product is not available"})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// mergeGuestCart moves a guest cart into the user's cart on login. When the
+// user has no cart yet the guest cart is simply claimed; otherwise quantities
+// of matching products are summed, capped at the available stock, and any
+// checkout holds of the guest cart are dropped.
+func mergeGuestCart(tx *gorm.DB, token string, userID string) error {
+	var guest entity.Cart
+	err := tx.Preload("Items").
+		Where("token_hash = ? AND user_id IS NULL", auth.HashOpaqueToken(token)).
+		First(&guest).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var cart entity.Cart
+	err = tx.Where("user_id = ?", userID).First(&cart).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return tx.Model(&guest).Updates(map[string]interface{}{
+			"user_id":    userID,
+			"token_hash": nil,
+		}).Error
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for _, guestItem := range guest.Items {
+		product, err := findAvailableProduct(tx, guestItem.ProductID)
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, errProductUnavailable) {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		variant, err := findAvailableVariant(tx, product.ID, guestItem.VariantID)
+		if errors.Is(err, errVariantNotFound) || errors.Is(err, errVariantRequired) || errors.Is(err, errProductUnavailable) {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		var item entity.CartItem
+		err = findCartLine(tx, cart.ID, guestItem.ProductID, guestItem.VariantID).First(&item).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		exists := err == nil
+
+		available, err := availableStock(tx, product.ID, variant, cart.ID)
+		if err != nil {
+			return err
+		}
+
+		quantity := min(guestItem.Quantity+item.Quantity, available)
+		if quantity < 1 {
+			continue
+		}
+
+		if exists {
+			err = tx.Model(&item).Update("quantity", quantity).Error
+		} else {
+			newItem := entity.NewCartItem(cart.ID, product, variant, quantity)
+			newItem.UnitPrice = guestItem.UnitPrice
+			err = tx.Create(newItem).Error
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := inventory.ReleaseCart(tx, guest.ID); err != nil {
+		return err
+	}
+
+	if err := tx.Where("cart_id = ?", guest.ID).Delete(&entity.CartItem{}).Error; err != nil {
+		return err
+	}
+
+	return tx.Delete(&guest).Error
+}
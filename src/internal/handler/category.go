@@ -4,28 +4,27 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"path/filepath"
 	"strconv"
 
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/gaspartv/api.ecommerce/src/config"
 	"github.com/gaspartv/api.ecommerce/src/external/storage"
 	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"github.com/gaspartv/api.ecommerce/src/internal/slug"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type CategoryHandle struct {
-	db  *gorm.DB
-	r2  *s3.Client
-	env *config.Env
+	db    *gorm.DB
+	store storage.ObjectStore
+	env   *config.Env
 }
 
-func NewCategoryHandler(db *gorm.DB, r2 *s3.Client, env *config.Env) *CategoryHandle {
+func NewCategoryHandler(db *gorm.DB, store storage.ObjectStore, env *config.Env) *CategoryHandle {
 	return &CategoryHandle{
-		db:  db,
-		r2:  r2,
-		env: env,
+		db:    db,
+		store: store,
+		env:   env,
 	}
 }
 
@@ -50,6 +49,23 @@ func (h *CategoryHandle) Create(ctx *gin.Context) {
 		return
 	}
 
+	if category.ParentID != nil {
+		if err := h.db.Where("id = ? AND deleted_at IS NULL", *category.ParentID).First(&entity.Category{}).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "Parent category not found"})
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	category.Slug, err = slug.Unique(h.db, "categories", entity.SlugEntityCategory, category.Name, "")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	result := h.db.Create(category)
 	if result.Error != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": result.Error.Error()})
@@ -148,7 +164,49 @@ func (h *CategoryHandle) GetByID(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"data": category})
+	h.respondCategory(ctx, category)
+}
+
+// GetBySlug finds an active category by its current slug and redirects
+// requests for a former slug to the current one.
+func (h *CategoryHandle) GetBySlug(ctx *gin.Context) {
+	slugParam := ctx.Param("slug")
+
+	var category entity.Category
+	err := h.db.Where("slug = ? AND deleted_at IS NULL AND disabled_at IS NULL", slugParam).First(&category).Error
+	if err == nil {
+		h.respondCategory(ctx, &category)
+		return
+	}
+
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var current string
+	if err := h.db.Model(&entity.Category{}).
+		Select("categories.slug").
+		Joins("JOIN slug_histories ON slug_histories.entity_id = categories.id").
+		Where("slug_histories.entity_type = ? AND slug_histories.slug = ?", entity.SlugEntityCategory, slugParam).
+		Where("categories.deleted_at IS NULL AND categories.disabled_at IS NULL").
+		Scan(&current).Error; err != nil {
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if current == "" {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	target := "/categories/by-slug/" + current
+	if rawQuery := ctx.Request.URL.RawQuery; rawQuery != "" {
+		target += "?" + rawQuery
+	}
+
+	ctx.Redirect(http.StatusMovedPermanently, target)
 }
 
 func (h *CategoryHandle) Edit(ctx *gin.Context) {
@@ -198,7 +256,19 @@ func (h *CategoryHandle) Edit(ctx *gin.Context) {
 		updates["description"] = *body.Description
 	}
 
-	if len(*body.Description) > 510 {
+	if body.ParentID != nil {
+		switch {
+		case *body.ParentID == "":
+			if current.ParentID != nil {
+				updates["parent_id"] = nil
+			}
+		case current.ParentID == nil || *current.ParentID != *body.ParentID:
+			// Checked inside the transaction below, under lock.
+			updates["parent_id"] = *body.ParentID
+		}
+	}
+
+	if body.Description != nil && len(*body.Description) > 510 {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Description exceeds maximum length of 510 characters"})
 		return
 	}
@@ -208,8 +278,27 @@ func (h *CategoryHandle) Edit(ctx *gin.Context) {
 		return
 	}
 
-	if err := h.db.Model(&entity.Category{}).Where("id = ? AND deleted_at IS NULL", idParam).Updates(updates).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if parentID, ok := updates["parent_id"].(string); ok {
+			if err := checkCategoryMove(tx, idParam, parentID); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&entity.Category{}).Where("id = ? AND deleted_at IS NULL", idParam).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		if name, ok := updates["name"].(string); ok {
+			if _, err := slug.Rename(tx, "categories", entity.SlugEntityCategory, idParam, current.Slug, name); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		respondError(ctx, err)
 		return
 	}
 
@@ -223,6 +312,17 @@ func (h *CategoryHandle) Delete(ctx *gin.Context) {
 		return
 	}
 
+	var children int64
+	if err := h.db.Model(&entity.Category{}).Where("parent_id = ? AND deleted_at IS NULL", idParam).Count(&children).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if children > 0 {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Category has subcategories"})
+		return
+	}
+
 	result := h.db.Where("id = ? AND deleted_at IS NULL", idParam).Delete(&entity.Category{})
 	if result.Error != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
@@ -290,37 +390,56 @@ func (h *CategoryHandle) ChangeImage(ctx *gin.Context) {
 		return
 	}
 
-	file, header, err := ctx.Request.FormFile("image")
+	header, err := ctx.FormFile("image")
 	if err != nil {
 		ctx.JSON(400, gin.H{"error": "Invalid file"})
 		return
 	}
-	defer file.Close()
 
-	ext := filepath.Ext(header.Filename)
-	key := fmt.Sprintf("categories/%s%s", id, ext)
+	var current entity.Category
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&current).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(404, gin.H{"error": "Category not found"})
+			return
+		}
+		ctx.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
 
-	url, err := storage.UploadToR2(h.r2, key, header.Header.Get("Content-Type"), file)
+	variants, err := storeImage(ctx.Request.Context(), h.db, h.store, h.env, header, "categories", false)
 	if err != nil {
-		ctx.JSON(500, gin.H{"error": err.Error()})
+		respondError(ctx, err)
 		return
 	}
 
 	result := h.db.Model(&entity.Category{}).
 		Where("id = ? AND deleted_at IS NULL", id).
-		Update("image", url)
+		Updates(map[string]interface{}{
+			"image":          variants[primaryRendition],
+			"image_variants": variants,
+		})
 
 	if result.Error != nil {
+		discardStored(ctx.Request.Context(), h.db, h.store, h.env, variants)
 		ctx.JSON(500, gin.H{"error": result.Error.Error()})
 		return
 	}
 
 	if result.RowsAffected == 0 {
+		discardStored(ctx.Request.Context(), h.db, h.store, h.env, variants)
 		ctx.JSON(404, gin.H{"error": "Category not found"})
 		return
 	}
 
-	ctx.JSON(200, gin.H{"message": "Image updated successfully"})
+	discardImage(ctx.Request.Context(), h.db, h.store, h.env, current.Image, current.ImageVariants)
+
+	ctx.JSON(200, gin.H{
+		"message": "Image updated successfully",
+		"data": gin.H{
+			"image":          variants[primaryRendition],
+			"image_variants": variants,
+		},
+	})
 }
 
 func (h *CategoryHandle) ListSelect(ctx *gin.Context) {
@@ -337,3 +456,16 @@ func (h *CategoryHandle) ListSelect(ctx *gin.Context) {
 		"data": categories,
 	})
 }
+
+func (h *CategoryHandle) respondCategory(ctx *gin.Context, category *entity.Category) {
+	breadcrumbs, err := categoryBreadcrumbs(h.db, category.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":        category,
+		"breadcrumbs": breadcrumbs,
+	})
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const categoryDescendantsSQL = `WITH RECURSIVE tree AS (
+	SELECT id FROM categories WHERE id = ? AND deleted_at IS NULL
+	UNION
+	SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id WHERE c.deleted_at IS NULL
+)
+SELECT id FROM tree`
+
+const categoryAncestorsSQL = `WITH RECURSIVE path AS (
+	SELECT id, name, slug, parent_id, 0 AS depth FROM categories WHERE id = ? AND deleted_at IS NULL
+	UNION
+	SELECT c.id, c.name, c.slug, c.parent_id, p.depth + 1 FROM categories c JOIN path p ON c.id = p.parent_id
+	WHERE c.deleted_at IS NULL AND p.depth < 100
+)
+SELECT id, name, slug FROM path ORDER BY depth DESC`
+
+func (h *CategoryHandle) Tree(ctx *gin.Context) {
+	query := h.db.Model(&entity.Category{}).Where("deleted_at IS NULL")
+
+	status := ctx.Query("status")
+	if status != "" {
+		switch status {
+		case "active":
+			query = query.Where("disabled_at IS NULL")
+		case "inactive":
+			query = query.Where("disabled_at IS NOT NULL")
+		default:
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status value"})
+			return
+		}
+	}
+
+	var categories []entity.Category
+	if err := query.Order("name").Find(&categories).Error; err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": buildCategoryTree(categories)})
+}
+
+func (h *CategoryHandle) Breadcrumbs(ctx *gin.Context) {
+	idParam := ctx.Query("id")
+	if idParam == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	path, err := categoryBreadcrumbs(h.db, idParam)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(path) == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": path})
+}
+
+// buildCategoryTree nests the categories under their parents. Categories
+// whose parent is missing from the list, e.g. filtered out by status, are
+// shown as roots.
+func buildCategoryTree(categories []entity.Category) []*entity.CategoryNode {
+	nodes := make(map[string]*entity.CategoryNode, len(categories))
+	for _, category := range categories {
+		nodes[category.ID] = &entity.CategoryNode{Category: category, Children: []*entity.CategoryNode{}}
+	}
+
+	roots := []*entity.CategoryNode{}
+	for _, category := range categories {
+		node := nodes[category.ID]
+		if category.ParentID != nil {
+			if parent, ok := nodes[*category.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	var sortNodes func([]*entity.CategoryNode)
+	sortNodes = func(list []*entity.CategoryNode) {
+		sort.SliceStable(list, func(i, j int) bool {
+			return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name)
+		})
+		for _, node := range list {
+			sortNodes(node.Children)
+		}
+	}
+	sortNodes(roots)
+
+	return roots
+}
+
+// categoryBreadcrumbs returns the path from the root down to the category,
+// or an empty path when the category does not exist.
+func categoryBreadcrumbs(tx *gorm.DB, id string) ([]entity.CategoryBreadcrumb, error) {
+	path := []entity.CategoryBreadcrumb{}
+	if err := tx.Raw(categoryAncestorsSQL, id).Scan(&path).Error; err != nil {
+		return nil, err
+	}
+	return path, nil
+}
+
+// categoryDescendantIDs returns the category and all categories below it.
+func categoryDescendantIDs(tx *gorm.DB, id string) ([]string, error) {
+	var ids []string
+	if err := tx.Raw(categoryDescendantsSQL, id).Scan(&ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// lockCategoryMove locks, in id order, a category about to move and the
+// path from its new parent up to the root. A concurrent move that could
+// close a cycle with this one must change a parent on that path, so it
+// waits for this transaction and then sees the new tree.
+func lockCategoryMove(tx *gorm.DB, id string, parentID string) error {
+	path, err := categoryBreadcrumbs(tx, parentID)
+	if err != nil {
+		return err
+	}
+
+	ids := []string{id}
+	for _, ancestor := range path {
+		ids = append(ids, ancestor.ID)
+	}
+
+	var locked []entity.Category
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&locked).Error
+}
+
+// checkCategoryMove makes sure a category can move under parentID: the
+// parent exists and is not the category itself or one of its descendants.
+// The check holds until the transaction ends.
+func checkCategoryMove(tx *gorm.DB, id string, parentID string) error {
+	if err := lockCategoryMove(tx, id, parentID); err != nil {
+		return err
+	}
+
+	if err := tx.Where("id = ? AND deleted_at IS NULL", parentID).First(&entity.Category{}).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &requestError{http.StatusBadRequest, "Parent category not found"}
+		}
+		return err
+	}
+
+	descendants, err := categoryDescendantIDs(tx, id)
+	if err != nil {
+		return err
+	}
+
+	for _, descendant := range descendants {
+		if descendant == parentID {
+			return &requestError{http.StatusBadRequest, "Category cannot be moved under itself or a subcategory"}
+		}
+	}
+
+	return nil
+}
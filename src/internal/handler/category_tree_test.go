@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+)
+
+// renderTree writes nodes as "name(children)" joined by commas.
+func renderTree(nodes []*entity.CategoryNode) string {
+	parts := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		part := node.Name
+		if len(node.Children) > 0 {
+			part += "(" + renderTree(node.Children) + ")"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestBuildCategoryTree(t *testing.T) {
+	category := func(id string, name string, parentID string) entity.Category {
+		c := entity.Category{ID: id, Name: name}
+		if parentID != "" {
+			c.ParentID = &parentID
+		}
+		return c
+	}
+
+	tests := []struct {
+		name       string
+		categories []entity.Category
+		want       string
+	}{
+		{"empty", nil, ""},
+		{
+			"nested and sorted case-insensitively",
+			[]entity.Category{
+				category("1", "Roupas", ""),
+				category("2", "camisetas", "1"),
+				category("3", "Calças", "1"),
+				category("4", "Manga longa", "2"),
+				category("5", "Acessórios", ""),
+			},
+			"Acessórios,Roupas(Calças,camisetas(Manga longa))",
+		},
+		{
+			"child listed before parent",
+			[]entity.Category{
+				category("2", "Tênis", "1"),
+				category("1", "Calçados", ""),
+			},
+			"Calçados(Tênis)",
+		},
+		{
+			"missing parent becomes a root",
+			[]entity.Category{
+				category("2", "Bonés", "gone"),
+				category("3", "Anéis", ""),
+			},
+			"Anéis,Bonés",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderTree(buildCategoryTree(tt.categories)); got != tt.want {
+				t.Errorf("tree = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
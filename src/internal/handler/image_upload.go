@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"github.com/gaspartv/api.ecommerce/src/config"
+	"github.com/gaspartv/api.ecommerce/src/external/storage"
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"github.com/gaspartv/api.ecommerce/src/internal/media"
+	"gorm.io/gorm"
+)
+
+// primaryRendition is the rendition kept in the single image column.
+const primaryRendition = "large"
+
+// discardGrace protects recently written objects of a replaced image: a
+// concurrent request with the same content may have just put them and not
+// written its row yet. The reconciler removes them later if orphaned.
+const discardGrace = 10 * time.Minute
+
+// storeImage validates an uploaded image, generates its renditions and
+// stores them under dir. Keys are content hashes, so an identical upload
+// writes to the same objects. With primaryOnly, for targets that keep a
+// single image column, only the primary rendition is generated.
+func storeImage(ctx context.Context, db *gorm.DB, store storage.ObjectStore, env *config.Env, header *multipart.FileHeader, dir string, primaryOnly bool) (entity.ImageVariants, error) {
+	data, err := readUpload(header, env)
+	if err != nil {
+		return nil, err
+	}
+
+	return storeImageData(ctx, db, store, env, data, dir, primaryOnly)
+}
+
+// storeImageData is storeImage for bytes already read.
+func storeImageData(ctx context.Context, db *gorm.DB, store storage.ObjectStore, env *config.Env, data []byte, dir string, primaryOnly bool) (entity.ImageVariants, error) {
+	var renditions []media.Rendition
+	if primaryOnly {
+		rendition, err := media.ProcessSize(data, primaryRendition)
+		if err != nil {
+			return nil, err
+		}
+		renditions = []media.Rendition{*rendition}
+	} else {
+		var err error
+		if renditions, err = media.Process(data); err != nil {
+			return nil, err
+		}
+	}
+
+	variants := entity.ImageVariants{}
+	for _, rendition := range renditions {
+		url, err := storeObject(ctx, store, dir, rendition.Ext, rendition.ContentType, rendition.Data)
+		if err != nil {
+			discardStored(ctx, db, store, env, variants)
+			return nil, err
+		}
+
+		variants[rendition.Name] = url
+	}
+
+	return variants, nil
+}
+
+func readUpload(header *multipart.FileHeader, env *config.Env) ([]byte, error) {
+	maxBytes := int64(env.ImageMaxBytes)
+	if header.Size > maxBytes {
+		return nil, media.ErrImageTooLarge
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, &requestError{http.StatusBadRequest, "Invalid file"}
+	}
+	defer file.Close()
+
+	return media.Read(file, maxBytes)
+}
+
+// storeObject puts data at dir/<sha256><ext> and returns its URL. The put
+// is not skipped when the key exists: rewriting the same content is
+// harmless and refreshes the modification time checked by discardImage.
+// Since a key never changes content, objects are marked immutable for
+// browsers and the CDN.
+func storeObject(ctx context.Context, store storage.ObjectStore, dir string, ext string, contentType string, data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	key := dir + "/" + hex.EncodeToString(sum[:]) + ext
+
+	err := store.Put(ctx, key, bytes.NewReader(data), storage.PutOptions{
+		ContentType:  contentType,
+		CacheControl: storage.ImmutableCacheControl,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return store.URL(key), nil
+}
+
+// discardImage removes the objects of an image that was replaced or
+// deleted. Objects written within discardGrace are kept, since another
+// request may be about to attach the same content.
+func discardImage(ctx context.Context, db *gorm.DB, store storage.ObjectStore, env *config.Env, image string, variants entity.ImageVariants) {
+	urls := []string{image}
+	for _, url := range variants {
+		urls = append(urls, url)
+	}
+
+	discardObjects(ctx, db, store, env, urls, true)
+}
+
+// discardStored removes the objects this request stored for an image it
+// did not attach.
+func discardStored(ctx context.Context, db *gorm.DB, store storage.ObjectStore, env *config.Env, variants entity.ImageVariants) {
+	urls := make([]string, 0, len(variants))
+	for _, url := range variants {
+		urls = append(urls, url)
+	}
+
+	discardObjects(ctx, db, store, env, urls, false)
+}
+
+// discardObjects deletes the objects behind urls. Since identical uploads
+// share objects, anything a row still uses is kept, as are the default
+// image and URLs outside the store. With grace, so are objects written
+// within discardGrace.
+func discardObjects(ctx context.Context, db *gorm.DB, store storage.ObjectStore, env *config.Env, urls []string, grace bool) {
+	seen := map[string]bool{}
+	for _, url := range urls {
+		if url == "" || url == env.IMAGE_CATEGORY_DEFAULT_URL || seen[url] {
+			continue
+		}
+		seen[url] = true
+
+		key, ok := storage.KeyFromURL(store, url)
+		if !ok {
+			continue
+		}
+
+		referenced, err := media.IsReferenced(db, url)
+		if err != nil {
+			log.Printf("Erro ao verificar uso da imagem %s: %v", key, err)
+			continue
+		}
+
+		if referenced {
+			continue
+		}
+
+		if grace {
+			info, err := store.Stat(ctx, key)
+			if errors.Is(err, storage.ErrNotFound) {
+				continue
+			}
+
+			if err != nil {
+				log.Printf("Erro ao consultar imagem %s no armazenamento: %v", key, err)
+				continue
+			}
+
+			if time.Since(info.LastModified) < discardGrace {
+				continue
+			}
+		}
+
+		if err := store.Delete(ctx, key); err != nil {
+			log.Printf("Erro ao remover imagem %s do armazenamento: %v", key, err)
+		}
+	}
+}
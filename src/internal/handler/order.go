@@ -0,0 +1,521 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gaspartv/api.ecommerce/src/config"
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"github.com/gaspartv/api.ecommerce/src/internal/inventory"
+	"github.com/gaspartv/api.ecommerce/src/internal/media"
+	"github.com/gaspartv/api.ecommerce/src/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// requestError is returned from inside transactions to roll back and still
+// answer with a specific status and message.
+type requestError struct {
+	status  int
+	message string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+func respondError(ctx *gin.Context, err error) {
+	var reqErr *requestError
+	switch {
+	case errors.As(err, &reqErr):
+		ctx.JSON(reqErr.status, gin.H{"error": reqErr.message})
+	case errors.Is(err, inventory.ErrInsufficientStock):
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Insufficient stock"})
+	case errors.Is(err, inventory.ErrInvalidMovement):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, inventory.ErrNoWarehouse):
+		ctx.JSON(http.StatusConflict, gin.H{"error": "No active warehouse"})
+	case errors.Is(err, media.ErrImageTooLarge):
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Image is too large"})
+	case errors.Is(err, media.ErrUnsupportedImage):
+		ctx.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "File is not a supported image"})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+var errCartRepriced = errors.New("cart prices changed")
+
+type OrderHandle struct {
+	db  *gorm.DB
+	env *config.Env
+}
+
+func NewOrderHandler(db *gorm.DB, env *config.Env) *OrderHandle {
+	return &OrderHandle{
+		db:  db,
+		env: env,
+	}
+}
+
+// StartCheckout holds the cart's units for the reservation TTL so they
+// cannot be sold to someone else while the shopper pays.
+func (h *OrderHandle) StartCheckout(ctx *gin.Context) {
+	user := middleware.CurrentUser(ctx)
+
+	var reservations []entity.StockReservation
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var cart entity.Cart
+		if err := tx.Preload("Items").Where("user_id = ?", user.ID).First(&cart).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &requestError{http.StatusBadRequest, "Cart is empty"}
+			}
+			return err
+		}
+
+		if len(cart.Items) == 0 {
+			return &requestError{http.StatusBadRequest, "Cart is empty"}
+		}
+
+		items := cart.Items
+		sort.Slice(items, func(i, j int) bool { return cartLineKey(items[i]) < cartLineKey(items[j]) })
+
+		for _, item := range items {
+			var product entity.Product
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ? AND deleted_at IS NULL AND disabled_at IS NULL", item.ProductID).
+				First(&product).Error; err != nil {
+
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return &requestError{http.StatusConflict, fmt.Sprintf("Product %s is no longer available", item.ProductID)}
+				}
+				return err
+			}
+
+			if item.VariantID != nil {
+				if _, err := lockVariant(tx, &product, *item.VariantID); err != nil {
+					return err
+				}
+			}
+		}
+
+		var err error
+		reservations, err = inventory.Reserve(tx, cart.ID, items, h.env.ReservationTTL)
+		return err
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	response := gin.H{"data": reservations}
+	if len(reservations) > 0 {
+		response["expires_at"] = reservations[0].ExpiresAt
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *OrderHandle) Checkout(ctx *gin.Context) {
+	user := middleware.CurrentUser(ctx)
+
+	var body entity.OrderCheckout
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.BindJSON(&body); err != nil {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var origin *inventory.Point
+	if body.Latitude != nil && body.Longitude != nil {
+		origin = &inventory.Point{Latitude: *body.Latitude, Longitude: *body.Longitude}
+	}
+
+	var order *entity.Order
+	repricedItems := map[string]float64{}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var cart entity.Cart
+		if err := tx.Preload("Items").Where("user_id = ?", user.ID).First(&cart).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &requestError{http.StatusBadRequest, "Cart is empty"}
+			}
+			return err
+		}
+
+		if len(cart.Items) == 0 {
+			return &requestError{http.StatusBadRequest, "Cart is empty"}
+		}
+
+		// Locking rows in a stable order keeps concurrent checkouts of the
+		// same products from deadlocking.
+		items := cart.Items
+		sort.Slice(items, func(i, j int) bool { return cartLineKey(items[i]) < cartLineKey(items[j]) })
+
+		order = entity.NewOrder(user.ID)
+
+		for _, item := range items {
+			var product entity.Product
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ? AND deleted_at IS NULL", item.ProductID).
+				First(&product).Error; err != nil {
+
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return &requestError{http.StatusConflict, fmt.Sprintf("Product %s is no longer available", item.ProductID)}
+				}
+				return err
+			}
+
+			if product.DisabledAt != nil {
+				return &requestError{http.StatusConflict, fmt.Sprintf("Product %s is no longer available", product.Name)}
+			}
+
+			if item.VariantID != nil {
+				variant, err := lockVariant(tx, &product, *item.VariantID)
+				if err != nil {
+					return err
+				}
+
+				available, err := inventory.AvailableVariant(tx, variant.ID, cart.ID)
+				if err != nil {
+					return err
+				}
+
+				if available < item.Quantity {
+					return &requestError{http.StatusConflict, fmt.Sprintf("Insufficient stock for %s", product.Name)}
+				}
+
+				if price := variant.EffectivePrice(&product); math.Abs(price-item.UnitPrice) >= 0.005 {
+					repricedItems[item.ID] = price
+					continue
+				}
+
+				movement := entity.NewInventoryMovement(product.ID, -item.Quantity, entity.InventorySale)
+				movement.VariantID = &variant.ID
+				movement.UserID = &user.ID
+				movement.OrderID = &order.ID
+				if err := inventory.Record(tx, movement); err != nil {
+					return err
+				}
+
+				orderItem := entity.NewOrderItem(order.ID, &product, variant, item.Quantity)
+				order.Items = append(order.Items, *orderItem)
+				order.Total += orderItem.Subtotal
+				continue
+			}
+
+			available, err := inventory.Available(tx, product.ID, cart.ID)
+			if err != nil {
+				return err
+			}
+
+			if available < item.Quantity {
+				return &requestError{http.StatusConflict, fmt.Sprintf("Insufficient stock for %s", product.Name)}
+			}
+
+			if math.Abs(product.Price-item.UnitPrice) >= 0.005 {
+				repricedItems[item.ID] = product.Price
+				continue
+			}
+
+			allocations, err := inventory.Allocate(tx, product.ID, item.Quantity, h.env.FulfillmentStrategy, origin)
+			if err != nil {
+				if errors.Is(err, inventory.ErrInsufficientStock) {
+					return &requestError{http.StatusConflict, fmt.Sprintf("Insufficient stock for %s", product.Name)}
+				}
+				return err
+			}
+
+			orderItem := entity.NewOrderItem(order.ID, &product, nil, item.Quantity)
+
+			for _, allocation := range allocations {
+				movement := entity.NewInventoryMovement(product.ID, -allocation.Quantity, entity.InventorySale)
+				movement.WarehouseID = &allocation.WarehouseID
+				movement.UserID = &user.ID
+				movement.OrderID = &order.ID
+				if err := inventory.Record(tx, movement); err != nil {
+					return err
+				}
+
+				orderItem.Allocations = append(orderItem.Allocations,
+					*entity.NewOrderItemAllocation(orderItem.ID, allocation.WarehouseID, allocation.Quantity))
+			}
+
+			order.Items = append(order.Items, *orderItem)
+			order.Total += orderItem.Subtotal
+		}
+
+		if len(repricedItems) > 0 {
+			return errCartRepriced
+		}
+
+		if err := tx.Create(order).Error; err != nil {
+			return err
+		}
+
+		if err := inventory.ConsumeCart(tx, cart.ID); err != nil {
+			return err
+		}
+
+		return tx.Where("cart_id = ?", cart.ID).Delete(&entity.CartItem{}).Error
+	})
+
+	if errors.Is(err, errCartRepriced) {
+		// The checkout was rolled back; only the cart snapshots are refreshed
+		// so the shopper can review the new prices before trying again.
+		for itemID, price := range repricedItems {
+			if err := h.db.Model(&entity.CartItem{}).Where("id = ?", itemID).Update("unit_price", price).Error; err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Cart prices have changed, review the cart before checking out"})
+		return
+	}
+
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": order})
+}
+
+func (h *OrderHandle) List(ctx *gin.Context) {
+	user := middleware.CurrentUser(ctx)
+
+	page, _ := strconv.Atoi(ctx.Query("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+	if limit < 1 {
+		limit = 20
+	}
+
+	orderDir := ctx.Query("order_dir")
+	if orderDir != "asc" && orderDir != "desc" {
+		orderDir = "desc"
+	}
+
+	offset := (page - 1) * limit
+
+	query := h.db.Model(&entity.Order{})
+
+	if user.HasPermission(entity.PermissionOrdersManage) {
+		if userID := ctx.Query("user_id"); userID != "" {
+			query = query.Where("user_id = ?", userID)
+		}
+	} else {
+		query = query.Where("user_id = ?", user.ID)
+	}
+
+	if status := entity.OrderStatus(ctx.Query("status")); status != "" {
+		if !status.Valid() {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status value"})
+			return
+		}
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var orders []entity.Order
+
+	if err := query.
+		Preload("Items.Allocations").
+		Limit(limit).
+		Offset(offset).
+		Order("created_at " + orderDir).
+		Find(&orders).Error; err != nil {
+
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  orders,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+func (h *OrderHandle) GetByID(ctx *gin.Context) {
+	idParam := ctx.Query("id")
+	if idParam == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	user := middleware.CurrentUser(ctx)
+	query := h.db.Preload("Items.Allocations").Where("id = ?", idParam)
+
+	if !user.HasPermission(entity.PermissionOrdersManage) {
+		query = query.Where("user_id = ?", user.ID)
+	}
+
+	var order entity.Order
+	if err := query.First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": order})
+}
+
+func (h *OrderHandle) ChangeStatus(ctx *gin.Context) {
+	idParam := ctx.Query("id")
+	if idParam == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	var body entity.OrderChangeStatus
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !body.Status.Valid() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status value"})
+		return
+	}
+
+	user := middleware.CurrentUser(ctx)
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		return transitionOrder(tx, idParam, body.Status, &user.ID)
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  body.Status,
+		"message": "Order status updated successfully",
+	})
+}
+
+// lockOrderTransition locks the order and checks that it may move to next.
+func lockOrderTransition(tx *gorm.DB, orderID string, next entity.OrderStatus) (*entity.Order, error) {
+	var order entity.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", orderID).
+		First(&order).Error; err != nil {
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &requestError{http.StatusNotFound, "Order not found"}
+		}
+		return nil, err
+	}
+
+	if !order.Status.CanTransitionTo(next) {
+		return nil, &requestError{
+			http.StatusConflict,
+			fmt.Sprintf("Cannot change order status from %s to %s", order.Status, next),
+		}
+	}
+
+	return &order, nil
+}
+
+// transitionOrder moves an order to the next status inside tx, enforcing the
+// lifecycle and giving stock back when the order is cancelled or refunded.
+// actorID is recorded on the stock movements and is nil for system events.
+func transitionOrder(tx *gorm.DB, orderID string, next entity.OrderStatus, actorID *string) error {
+	order, err := lockOrderTransition(tx, orderID, next)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Model(order).Update("status", next).Error; err != nil {
+		return err
+	}
+
+	if !next.RestoresStock() {
+		return nil
+	}
+
+	if err := tx.Preload("Allocations").Where("order_id = ?", order.ID).Find(&order.Items).Error; err != nil {
+		return err
+	}
+
+	for _, item := range order.Items {
+		if item.VariantID != nil {
+			movement := entity.NewInventoryMovement(item.ProductID, item.Quantity, entity.InventoryReturn)
+			movement.VariantID = item.VariantID
+			movement.UserID = actorID
+			movement.OrderID = &order.ID
+			if err := inventory.Record(tx, movement); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Orders placed before warehouses existed have no allocations and
+		// are restocked into the default warehouse.
+		allocations := item.Allocations
+		if len(allocations) == 0 {
+			allocations = []entity.OrderItemAllocation{{Quantity: item.Quantity}}
+		}
+
+		for _, allocation := range allocations {
+			movement := entity.NewInventoryMovement(item.ProductID, allocation.Quantity, entity.InventoryReturn)
+			if allocation.WarehouseID != "" {
+				movement.WarehouseID = &allocation.WarehouseID
+			}
+			movement.UserID = actorID
+			movement.OrderID = &order.ID
+			if err := inventory.Record(tx, movement); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func cartLineKey(item entity.CartItem) string {
+	if item.VariantID == nil {
+		return item.ProductID
+	}
+	return item.ProductID + "/" + *item.VariantID
+}
+
+// lockVariant locks a live variant of product for the rest of the checkout
+// and loads the option values used for its label.
+func lockVariant(tx *gorm.DB, product *entity.Product, variantID string) (*entity.ProductVariant, error) {
+	var variant entity.ProductVariant
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND product_id = ? AND deleted_at IS NULL AND disabled_at IS NULL", variantID, product.ID).
+		First(&variant).Error; err != nil {
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &requestError{http.StatusConflict, fmt.Sprintf("Variant of %s is no longer available", product.Name)}
+		}
+		return nil, err
+	}
+
+	if err := tx.Preload("OptionValues.OptionType").Where("id = ?", variant.ID).First(&variant).Error; err != nil {
+		return nil, err
+	}
+
+	return &variant, nil
+}
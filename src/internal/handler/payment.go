@@ -0,0 +1,245 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gaspartv/api.ecommerce/src/config"
+	"github.com/gaspartv/api.ecommerce/src/external/payment"
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"github.com/gaspartv/api.ecommerce/src/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PaymentHandle struct {
+	db       *gorm.DB
+	provider payment.PaymentProvider
+	env      *config.Env
+}
+
+func NewPaymentHandler(db *gorm.DB, provider payment.PaymentProvider, env *config.Env) *PaymentHandle {
+	return &PaymentHandle{
+		db:       db,
+		provider: provider,
+		env:      env,
+	}
+}
+
+func (h *PaymentHandle) Authorize(ctx *gin.Context) {
+	user := middleware.CurrentUser(ctx)
+
+	var body entity.PaymentAuthorize
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	var order entity.Order
+	if err := h.db.Where("id = ? AND user_id = ?", body.OrderID, user.ID).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if order.Status != entity.OrderPending {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Order is not awaiting payment"})
+		return
+	}
+
+	err := h.db.Where("order_id = ? AND status IN ?", order.ID, []payment.Status{
+		payment.StatusPending,
+		payment.StatusAuthorized,
+		payment.StatusCaptured,
+	}).First(&entity.Payment{}).Error
+	if err == nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Order already has an active payment"})
+		return
+	}
+
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.provider.Authorize(ctx.Request.Context(), payment.AuthorizeRequest{
+		OrderID: order.ID,
+		Amount:  order.Total,
+		Token:   body.Token,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	record := entity.NewPayment(order.ID, h.provider.Name(), order.Total, result)
+	if err := h.db.Create(record).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch result.Status {
+	case payment.StatusDeclined:
+		ctx.JSON(http.StatusPaymentRequired, gin.H{"error": "Payment declined", "data": record})
+	case payment.StatusPending:
+		ctx.JSON(http.StatusAccepted, gin.H{"data": record})
+	default:
+		ctx.JSON(http.StatusOK, gin.H{"data": record})
+	}
+}
+
+func (h *PaymentHandle) Capture(ctx *gin.Context) {
+	user := middleware.CurrentUser(ctx)
+
+	var body entity.PaymentOrder
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.settlePayment(ctx.Request.Context(), body.OrderID, payment.StatusAuthorized, payment.StatusCaptured, h.provider.Capture, &user.ID)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Payment captured successfully"})
+}
+
+func (h *PaymentHandle) Refund(ctx *gin.Context) {
+	user := middleware.CurrentUser(ctx)
+
+	var body entity.PaymentOrder
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.settlePayment(ctx.Request.Context(), body.OrderID, payment.StatusCaptured, payment.StatusRefunded, h.provider.Refund, &user.ID)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Payment refunded successfully"})
+}
+
+func (h *PaymentHandle) Webhook(ctx *gin.Context) {
+	payload, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, err := h.provider.VerifyWebhook(payload, ctx.GetHeader(payment.SignatureHeader))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid webhook signature"})
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		var record entity.Payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("reference = ?", event.Reference).
+			First(&record).Error; err != nil {
+
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &requestError{http.StatusNotFound, "Payment not found"}
+			}
+			return err
+		}
+
+		// Providers retry deliveries and do not keep them in order, so an
+		// event already applied, or older than the current status, is a no-op.
+		if !record.Status.CanTransitionTo(event.Status) {
+			if record.Status != event.Status {
+				log.Printf("Evento de pagamento %s ignorado: status %s não pode voltar para %s", record.Reference, record.Status, event.Status)
+			}
+			return nil
+		}
+
+		return applyPaymentStatus(tx, &record, event.Status, nil)
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Event processed"})
+}
+
+// paymentOrderStatus is the order status a payment status moves the order
+// to: a capture marks it paid, a refund marks it refunded and restocks.
+var paymentOrderStatus = map[payment.Status]entity.OrderStatus{
+	payment.StatusCaptured: entity.OrderPaid,
+	payment.StatusRefunded: entity.OrderRefunded,
+}
+
+// settlePayment moves the order's payment from one status to the next with
+// call, the matching provider operation. The payment and order rows are
+// locked and the order transition checked before the provider is called, so
+// money only moves when the database can record it.
+func (h *PaymentHandle) settlePayment(ctx context.Context, orderID string, from payment.Status, to payment.Status, call func(context.Context, string, float64) (*payment.Result, error), actorID *string) error {
+	return h.db.Transaction(func(tx *gorm.DB) error {
+		var record entity.Payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_id = ? AND status = ?", orderID, from).
+			First(&record).Error; err != nil {
+
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &requestError{http.StatusNotFound, "No " + string(from) + " payment found for this order"}
+			}
+			return err
+		}
+
+		if _, err := lockOrderTransition(tx, record.OrderID, paymentOrderStatus[to]); err != nil {
+			return err
+		}
+
+		if _, err := call(ctx, record.Reference, record.Amount); err != nil {
+			return providerError(err)
+		}
+
+		return applyPaymentStatus(tx, &record, to, actorID)
+	})
+}
+
+// applyPaymentStatus stores the new status of a payment locked by the
+// caller and moves the order along with it.
+func applyPaymentStatus(tx *gorm.DB, record *entity.Payment, status payment.Status, actorID *string) error {
+	if !record.Status.CanTransitionTo(status) {
+		return &requestError{
+			http.StatusConflict,
+			fmt.Sprintf("Cannot change payment status from %s to %s", record.Status, status),
+		}
+	}
+
+	if err := tx.Model(record).Update("status", status).Error; err != nil {
+		return err
+	}
+
+	if next, ok := paymentOrderStatus[status]; ok {
+		return transitionOrder(tx, record.OrderID, next, actorID)
+	}
+
+	return nil
+}
+
+func providerError(err error) error {
+	switch {
+	case errors.Is(err, payment.ErrNotFound):
+		return &requestError{http.StatusNotFound, "Payment not found at provider"}
+	case errors.Is(err, payment.ErrInvalidState):
+		return &requestError{http.StatusConflict, err.Error()}
+	default:
+		return &requestError{http.StatusBadGateway, err.Error()}
+	}
+}
@@ -4,28 +4,30 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"path/filepath"
 	"strconv"
 
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/gaspartv/api.ecommerce/src/config"
 	"github.com/gaspartv/api.ecommerce/src/external/storage"
 	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"github.com/gaspartv/api.ecommerce/src/internal/inventory"
+	"github.com/gaspartv/api.ecommerce/src/internal/middleware"
+	"github.com/gaspartv/api.ecommerce/src/internal/slug"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProductHandle struct {
-	db  *gorm.DB
-	r2  *s3.Client
-	env *config.Env
+	db    *gorm.DB
+	store storage.ObjectStore
+	env   *config.Env
 }
 
-func NewProductHandler(db *gorm.DB, r2 *s3.Client, env *config.Env) *ProductHandle {
+func NewProductHandler(db *gorm.DB, store storage.ObjectStore, env *config.Env) *ProductHandle {
 	return &ProductHandle{
-		db:  db,
-		r2:  r2,
-		env: env,
+		db:    db,
+		store: store,
+		env:   env,
 	}
 }
 
@@ -74,6 +76,19 @@ func (h *ProductHandle) List(ctx *gin.Context) {
 		}
 	}
 
+	if categoryID := ctx.Query("category_id"); categoryID != "" {
+		if ctx.Query("include_descendants") == "true" {
+			categoryIDs, err := categoryDescendantIDs(h.db, categoryID)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			query = query.Where("products.category_id IN ?", categoryIDs)
+		} else {
+			query = query.Where("products.category_id = ?", categoryID)
+		}
+	}
+
 	var total int64
 	if err := query.Count(&total).Error; err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -83,7 +98,7 @@ func (h *ProductHandle) List(ctx *gin.Context) {
 	var products []entity.ProductWithCategory
 
 	if err := query.
-		Select("products.*, categories.name as category_name").
+		Select(productWithCategorySelect).
 		Joins("LEFT JOIN categories ON products.category_id = categories.id").
 		Limit(limit).
 		Offset(offset).
@@ -93,6 +108,11 @@ func (h *ProductHandle) List(ctx *gin.Context) {
 		return
 	}
 
+	if err := attachVariants(h.db, products); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"data":  products,
 		"page":  page,
@@ -109,13 +129,43 @@ func (h *ProductHandle) Create(ctx *gin.Context) {
 		return
 	}
 
+	user := middleware.CurrentUser(ctx)
 	product := entity.NewProduct(productCreate, h.env)
 
-	if err := h.db.Create(&product).Error; err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	// The opening stock goes through the ledger like any other movement.
+	initialStock := product.StockQuantity
+	product.StockQuantity = 0
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkProductSku(tx, product.Sku); err != nil {
+			return err
+		}
+
+		var err error
+		product.Slug, err = slug.Unique(tx, "products", entity.SlugEntityProduct, product.Name, "")
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Create(product).Error; err != nil {
+			return &requestError{http.StatusBadRequest, err.Error()}
+		}
+
+		if initialStock == 0 {
+			return nil
+		}
+
+		movement := entity.NewInventoryMovement(product.ID, initialStock, entity.InventoryPurchase)
+		movement.UserID = &user.ID
+		movement.Note = "Initial stock"
+		return inventory.Record(tx, movement)
+	})
+	if err != nil {
+		respondError(ctx, err)
 		return
 	}
 
+	product.StockQuantity = initialStock
 	ctx.JSON(http.StatusCreated, gin.H{"data": product})
 }
 
@@ -156,10 +206,6 @@ func (h *ProductHandle) Edit(ctx *gin.Context) {
 		updates["price"] = *body.Price
 	}
 
-	if body.StockQuantity != nil {
-		updates["stock_quantity"] = *body.StockQuantity
-	}
-
 	if body.CategoryID != nil {
 		updates["category_id"] = *body.CategoryID
 	}
@@ -180,13 +226,63 @@ func (h *ProductHandle) Edit(ctx *gin.Context) {
 		updates["is_featured"] = *body.IsFeatured
 	}
 
-	if len(updates) == 0 {
+	if body.ReorderPoint != nil {
+		updates["reorder_point"] = *body.ReorderPoint
+	}
+
+	if body.ReorderQuantity != nil {
+		updates["reorder_quantity"] = *body.ReorderQuantity
+	}
+
+	if len(updates) == 0 && body.StockQuantity == nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
 		return
 	}
 
-	if err := h.db.Model(&entity.Product{}).Where("id = ? AND deleted_at IS NULL", idParam).Updates(updates).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	user := middleware.CurrentUser(ctx)
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if body.Sku != nil {
+			if err := checkProductSku(tx, *body.Sku); err != nil {
+				return err
+			}
+		}
+
+		if len(updates) > 0 {
+			if err := tx.Model(&entity.Product{}).Where("id = ? AND deleted_at IS NULL", idParam).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		if body.Name != nil {
+			if _, err := slug.Rename(tx, "products", entity.SlugEntityProduct, idParam, current.Slug, *body.Name); err != nil {
+				return err
+			}
+		}
+
+		if body.StockQuantity == nil {
+			return nil
+		}
+
+		// A stock value sent through edit is recorded as an adjustment for
+		// the difference, so the ledger still explains the new balance.
+		var locked entity.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", idParam).First(&locked).Error; err != nil {
+			return err
+		}
+
+		delta := *body.StockQuantity - locked.StockQuantity
+		if delta == 0 {
+			return nil
+		}
+
+		movement := entity.NewInventoryMovement(idParam, delta, entity.InventoryAdjustment)
+		movement.UserID = &user.ID
+		movement.Note = "Stock set through product edit"
+		return inventory.RecordSpread(tx, movement, h.env.FulfillmentStrategy)
+	})
+	if err != nil {
+		respondError(ctx, err)
 		return
 	}
 
@@ -267,35 +363,61 @@ func (h *ProductHandle) ChangeImage(ctx *gin.Context) {
 		return
 	}
 
-	file, header, err := ctx.Request.FormFile("image")
+	header, err := ctx.FormFile("image")
 	if err != nil {
 		ctx.JSON(400, gin.H{"error": "Invalid file"})
 		return
 	}
-	defer file.Close()
 
-	ext := filepath.Ext(header.Filename)
-	key := fmt.Sprintf("categories/%s%s", id, ext)
-
-	url, err := storage.UploadToR2(h.r2, key, header.Header.Get("Content-Type"), file)
-	if err != nil {
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&entity.Product{}).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(404, gin.H{"error": "Product not found"})
+			return
+		}
 		ctx.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
 
-	result := h.db.Model(&entity.Product{}).
-		Where("id = ? AND deleted_at IS NULL", id).
-		Update("image", url)
-
-	if result.Error != nil {
-		ctx.JSON(500, gin.H{"error": result.Error.Error()})
+	variants, err := storeImage(ctx.Request.Context(), h.db, h.store, h.env, header, "products", false)
+	if err != nil {
+		respondError(ctx, err)
 		return
 	}
 
-	if result.RowsAffected == 0 {
-		ctx.JSON(404, gin.H{"error": "Product not found"})
+	// The new image replaces the primary one of the gallery, which keeps
+	// products.image in sync.
+	var replacedImage string
+	var replacedVariants entity.ImageVariants
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		var product entity.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND deleted_at IS NULL", id).
+			First(&product).Error; err != nil {
+
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &requestError{http.StatusNotFound, "Product not found"}
+			}
+			return err
+		}
+
+		var err error
+		replacedImage, replacedVariants, err = replacePrimaryImage(tx, h.store, &product, variants, h.env.IMAGE_CATEGORY_DEFAULT_URL)
+		return err
+	})
+	if err != nil {
+		discardStored(ctx.Request.Context(), h.db, h.store, h.env, variants)
+		respondError(ctx, err)
 		return
 	}
 
-	ctx.JSON(200, gin.H{"message": "Image updated successfully"})
+	discardImage(ctx.Request.Context(), h.db, h.store, h.env, replacedImage, replacedVariants)
+
+	ctx.JSON(200, gin.H{
+		"message": "Image updated successfully",
+		"data": gin.H{
+			"image":          variants[primaryRendition],
+			"image_variants": variants,
+		},
+	})
 }
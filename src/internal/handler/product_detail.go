@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"github.com/gaspartv/api.ecommerce/src/internal/inventory"
+	"github.com/gaspartv/api.ecommerce/src/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const productWithCategorySelect = "products.*, categories.name as category_name, " +
+	inventory.AvailableQuantitySQL + " as available_quantity"
+
+// GetByID returns one product by id or SKU with its category, variants,
+// stock availability and related products. Disabled products are only
+// visible to catalog staff.
+func (h *ProductHandle) GetByID(ctx *gin.Context) {
+	query := h.db.Model(&entity.Product{}).
+		Select(productWithCategorySelect).
+		Joins("LEFT JOIN categories ON products.category_id = categories.id").
+		Where("products.deleted_at IS NULL")
+
+	switch {
+	case ctx.Query("id") != "":
+		query = query.Where("products.id = ?", ctx.Query("id"))
+	case ctx.Query("sku") != "":
+		query = query.Where("products.sku = ?", ctx.Query("sku"))
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID or SKU parameter is required"})
+		return
+	}
+
+	user := middleware.CurrentUser(ctx)
+	if !user.HasPermission(entity.PermissionCatalogWrite) {
+		query = query.Where("products.disabled_at IS NULL")
+	}
+
+	h.respondProductDetail(ctx, query)
+}
+
+// GetBySlug is the public detail view addressed by slug. Requests for a
+// former slug are redirected to the current one.
+func (h *ProductHandle) GetBySlug(ctx *gin.Context) {
+	slugParam := ctx.Param("slug")
+
+	var count int64
+	if err := h.db.Model(&entity.Product{}).
+		Where("slug = ? AND deleted_at IS NULL AND disabled_at IS NULL", slugParam).
+		Count(&count).Error; err != nil {
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if count == 0 {
+		var current string
+		if err := h.db.Model(&entity.Product{}).
+			Select("products.slug").
+			Joins("JOIN slug_histories ON slug_histories.entity_id = products.id").
+			Where("slug_histories.entity_type = ? AND slug_histories.slug = ?", entity.SlugEntityProduct, slugParam).
+			Where("products.deleted_at IS NULL AND products.disabled_at IS NULL").
+			Scan(&current).Error; err != nil {
+
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if current == "" {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+
+		target := "/products/by-slug/" + current
+		if rawQuery := ctx.Request.URL.RawQuery; rawQuery != "" {
+			target += "?" + rawQuery
+		}
+
+		ctx.Redirect(http.StatusMovedPermanently, target)
+		return
+	}
+
+	query := h.db.Model(&entity.Product{}).
+		Select(productWithCategorySelect).
+		Joins("LEFT JOIN categories ON products.category_id = categories.id").
+		Where("products.slug = ? AND products.deleted_at IS NULL AND products.disabled_at IS NULL", slugParam)
+
+	h.respondProductDetail(ctx, query)
+}
+
+func (h *ProductHandle) respondProductDetail(ctx *gin.Context, query *gorm.DB) {
+	var product entity.ProductWithCategory
+	if err := query.Take(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	products := []entity.ProductWithCategory{product}
+	if err := attachVariants(h.db, products); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, _ := strconv.Atoi(ctx.Query("related_limit"))
+	if limit < 1 || limit > 20 {
+		limit = 4
+	}
+
+	var related []entity.ProductWithCategory
+	if err := h.db.Model(&entity.Product{}).
+		Select(productWithCategorySelect).
+		Joins("LEFT JOIN categories ON products.category_id = categories.id").
+		Where("products.category_id = ? AND products.id <> ?", product.CategoryID, product.ID).
+		Where("products.deleted_at IS NULL AND products.disabled_at IS NULL").
+		Order("products.is_featured desc, products.created_at desc").
+		Limit(limit).
+		Scan(&related).Error; err != nil {
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	images := []entity.ProductImage{}
+	if err := h.db.Where("product_id = ?", product.ID).Order("position, created_at").Find(&images).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	breadcrumbs, err := categoryBreadcrumbs(h.db, product.CategoryID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"breadcrumbs": breadcrumbs,
+		"data": entity.ProductDetail{
+			ProductWithCategory: products[0],
+			Images:              images,
+			InStock:             product.AvailableQuantity > 0 || hasVariantStock(products[0].Variants),
+			Related:             related,
+		},
+	})
+}
+
+func hasVariantStock(variants []entity.ProductVariant) bool {
+	for _, variant := range variants {
+		if variant.DisabledAt == nil && variant.StockQuantity > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,335 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gaspartv/api.ecommerce/src/external/storage"
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxImagesPerUpload = 10
+
+// UploadImages adds the files of the "images" form field to the end of the
+// product gallery. Optional "alt_text" fields are matched by position. The
+// first image of an empty gallery becomes the primary one.
+func (h *ProductHandle) UploadImages(ctx *gin.Context) {
+	id := ctx.Query("id")
+	if id == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid form"})
+		return
+	}
+
+	files := form.File["images"]
+	if len(files) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "At least one image is required"})
+		return
+	}
+
+	if len(files) > maxImagesPerUpload {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d images per upload", maxImagesPerUpload)})
+		return
+	}
+
+	altTexts := form.Value["alt_text"]
+
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&entity.Product{}).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var position int
+	if err := h.db.Model(&entity.ProductImage{}).
+		Select("COALESCE(MAX(position) + 1, 0)").
+		Where("product_id = ?", id).
+		Scan(&position).Error; err != nil {
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	images := make([]entity.ProductImage, 0, len(files))
+	for i, header := range files {
+		altText := ""
+		if i < len(altTexts) {
+			altText = altTexts[i]
+		}
+
+		image := entity.NewProductImage(id, position+i, truncate(altText, 255))
+		image.Variants, err = storeImage(ctx.Request.Context(), h.db, h.store, h.env, header, "products", false)
+		if err != nil {
+			h.discardImages(ctx, images)
+			respondError(ctx, err)
+			return
+		}
+		image.URL = image.Variants[primaryRendition]
+		image.Key, _ = storage.KeyFromURL(h.store, image.URL)
+
+		images = append(images, *image)
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&images).Error; err != nil {
+			return err
+		}
+		return syncPrimaryImage(tx, id, h.env.IMAGE_CATEGORY_DEFAULT_URL)
+	})
+	if err != nil {
+		h.discardImages(ctx, images)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respondImages(ctx, http.StatusCreated, id)
+}
+
+func (h *ProductHandle) ListImages(ctx *gin.Context) {
+	id := ctx.Query("id")
+	if id == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	h.respondImages(ctx, http.StatusOK, id)
+}
+
+// ReorderImages sets the gallery order to the given list, which must name
+// every image of the product exactly once.
+func (h *ProductHandle) ReorderImages(ctx *gin.Context) {
+	var body entity.ProductImageReorder
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var ids []string
+		if err := tx.Model(&entity.ProductImage{}).Where("product_id = ?", body.ProductID).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+
+		known := make(map[string]bool, len(ids))
+		for _, id := range ids {
+			known[id] = true
+		}
+
+		if len(body.ImageIDs) != len(ids) {
+			return &requestError{http.StatusBadRequest, "Image list must contain every image of the product"}
+		}
+
+		for position, id := range body.ImageIDs {
+			if !known[id] {
+				return &requestError{http.StatusBadRequest, "Image list must contain every image of the product once"}
+			}
+			delete(known, id)
+
+			if err := tx.Model(&entity.ProductImage{}).Where("id = ?", id).Update("position", position).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	h.respondImages(ctx, http.StatusOK, body.ProductID)
+}
+
+func (h *ProductHandle) EditImage(ctx *gin.Context) {
+	idParam := ctx.Query("id")
+	if idParam == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	var body entity.ProductImageEdit
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	if body.AltText == nil && body.IsPrimary == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+		return
+	}
+
+	var image entity.ProductImage
+	if err := h.db.Where("id = ?", idParam).First(&image).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if body.AltText != nil {
+			if err := tx.Model(&image).Update("alt_text", *body.AltText).Error; err != nil {
+				return err
+			}
+		}
+
+		// Only promoting is accepted; the primary image changes when another
+		// one is made primary or when it is deleted.
+		if body.IsPrimary != nil && *body.IsPrimary && !image.IsPrimary {
+			if err := tx.Model(&entity.ProductImage{}).
+				Where("product_id = ? AND is_primary", image.ProductID).
+				Update("is_primary", false).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Model(&image).Update("is_primary", true).Error; err != nil {
+				return err
+			}
+		}
+
+		return syncPrimaryImage(tx, image.ProductID, h.env.IMAGE_CATEGORY_DEFAULT_URL)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respondImages(ctx, http.StatusOK, image.ProductID)
+}
+
+// DeleteImage removes an image from the gallery and its renditions from
+// storage. When the primary image is deleted the next one takes its place.
+func (h *ProductHandle) DeleteImage(ctx *gin.Context) {
+	idParam := ctx.Query("id")
+	if idParam == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	var image entity.ProductImage
+	if err := h.db.Where("id = ?", idParam).First(&image).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&image).Error; err != nil {
+			return err
+		}
+		return syncPrimaryImage(tx, image.ProductID, h.env.IMAGE_CATEGORY_DEFAULT_URL)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The row is gone either way; a failed object delete only leaves an
+	// orphan in the bucket.
+	discardImage(ctx.Request.Context(), h.db, h.store, h.env, image.URL, image.Variants)
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Image deleted successfully"})
+}
+
+func (h *ProductHandle) respondImages(ctx *gin.Context, status int, productID string) {
+	var images []entity.ProductImage
+	if err := h.db.Where("product_id = ?", productID).Order("position, created_at").Find(&images).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(status, gin.H{"data": images})
+}
+
+// discardImages removes objects already uploaded by a request that failed.
+func (h *ProductHandle) discardImages(ctx *gin.Context, images []entity.ProductImage) {
+	for _, image := range images {
+		discardStored(ctx.Request.Context(), h.db, h.store, h.env, image.Variants)
+	}
+}
+
+// syncPrimaryImage makes sure a gallery with images has exactly one primary
+// image, the first by position when none is flagged, and mirrors its URLs
+// into products.image and products.image_variants. An empty gallery falls back to defaultImage.
+func syncPrimaryImage(tx *gorm.DB, productID string, defaultImage string) error {
+	var primary entity.ProductImage
+	err := tx.Where("product_id = ?", productID).
+		Order("is_primary desc, position, created_at").
+		First(&primary).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return tx.Model(&entity.Product{}).Where("id = ?", productID).Updates(map[string]interface{}{
+			"image":          defaultImage,
+			"image_variants": nil,
+		}).Error
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !primary.IsPrimary {
+		if err := tx.Model(&primary).Update("is_primary", true).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(&entity.Product{}).Where("id = ?", productID).Updates(map[string]interface{}{
+		"image":          primary.URL,
+		"image_variants": primary.Variants,
+	}).Error
+}
+
+// replacePrimaryImage puts new renditions in the gallery as the primary image,
+// taking the place of the current primary one, and mirrors them into the
+// product. It returns the image that was replaced: the removed gallery row
+// or, for a product without a gallery, its former image. The product row
+// must be locked by the caller.
+func replacePrimaryImage(tx *gorm.DB, store storage.ObjectStore, product *entity.Product, variants entity.ImageVariants, defaultImage string) (string, entity.ImageVariants, error) {
+	image := entity.NewProductImage(product.ID, 0, "")
+	image.URL = variants[primaryRendition]
+	image.Variants = variants
+	image.Key, _ = storage.KeyFromURL(store, image.URL)
+	image.IsPrimary = true
+
+	replacedImage, replacedVariants := product.Image, product.ImageVariants
+
+	var replaced entity.ProductImage
+	err := tx.Where("product_id = ? AND is_primary", product.ID).First(&replaced).Error
+	switch {
+	case err == nil:
+		image.Position = replaced.Position
+		replacedImage, replacedVariants = replaced.URL, replaced.Variants
+
+		if err := tx.Delete(&replaced).Error; err != nil {
+			return "", nil, err
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return "", nil, err
+	}
+
+	if err := tx.Create(image).Error; err != nil {
+		return "", nil, err
+	}
+
+	if err := syncPrimaryImage(tx, product.ID, defaultImage); err != nil {
+		return "", nil, err
+	}
+
+	return replacedImage, replacedVariants, nil
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"github.com/gaspartv/api.ecommerce/src/internal/importer"
+	"github.com/gaspartv/api.ecommerce/src/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Import starts a background import of the CSV or XLSX file sent in the
+// "file" form field. With validate_only=true the file is only checked.
+// Progress is polled through ImportStatus.
+func (h *ProductHandle) Import(ctx *gin.Context) {
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file"})
+		return
+	}
+
+	maxBytes := int64(h.env.ProductImportMaxBytes)
+	if header.Size > maxBytes {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File is too large"})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file"})
+		return
+	}
+
+	if int64(len(data)) > maxBytes {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File is too large"})
+		return
+	}
+
+	rows, err := importer.Parse(header.Filename, data)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, importer.ErrUnsupportedFormat) {
+			status = http.StatusUnsupportedMediaType
+		}
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(rows) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "File has no rows"})
+		return
+	}
+
+	user := middleware.CurrentUser(ctx)
+	job := entity.NewProductImport(&user.ID, truncate(header.Filename, 255), ctx.PostForm("validate_only") == "true")
+	job.TotalRows = len(rows)
+
+	if err := h.db.Create(job).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"data": job})
+
+	go importer.Run(h.db, h.env, job, rows)
+}
+
+func (h *ProductHandle) ImportStatus(ctx *gin.Context) {
+	idParam := ctx.Query("id")
+	if idParam == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	var job entity.ProductImport
+	if err := h.db.Where("id = ?", idParam).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Import not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": job})
+}
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"github.com/gaspartv/api.ecommerce/src/internal/inventory"
+	"github.com/gaspartv/api.ecommerce/src/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AdjustStock records a movement for a product or, with variant_id, for one
+// of its variants. Variant stock is not kept per warehouse.
+func (h *ProductHandle) AdjustStock(ctx *gin.Context) {
+	var body entity.InventoryAdjust
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	if body.VariantID != "" && body.WarehouseID != "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Variant stock is not kept per warehouse"})
+		return
+	}
+
+	user := middleware.CurrentUser(ctx)
+
+	movement := entity.NewInventoryMovement(body.ProductID, body.Quantity, body.Reason)
+	movement.UserID = &user.ID
+	movement.Note = body.Note
+
+	if body.VariantID != "" {
+		movement.VariantID = &body.VariantID
+	}
+
+	if body.WarehouseID != "" {
+		movement.WarehouseID = &body.WarehouseID
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if body.VariantID != "" {
+			err := tx.Where("id = ? AND product_id = ? AND deleted_at IS NULL", body.VariantID, body.ProductID).First(&entity.ProductVariant{}).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &requestError{http.StatusNotFound, "Variant not found"}
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if body.WarehouseID != "" {
+			err := tx.Where("id = ? AND deleted_at IS NULL", body.WarehouseID).First(&entity.Warehouse{}).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &requestError{http.StatusNotFound, "Warehouse not found"}
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return inventory.Record(tx, movement)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": movement})
+}
+
+func (h *ProductHandle) StockHistory(ctx *gin.Context) {
+	idParam := ctx.Query("id")
+	if idParam == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	page, _ := strconv.Atoi(ctx.Query("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+	if limit < 1 {
+		limit = 20
+	}
+
+	offset := (page - 1) * limit
+
+	query := h.db.Model(&entity.InventoryMovement{}).Where("product_id = ?", idParam)
+
+	if reason := ctx.Query("reason"); reason != "" {
+		query = query.Where("reason = ?", reason)
+	}
+
+	if warehouseID := ctx.Query("warehouse_id"); warehouseID != "" {
+		query = query.Where("warehouse_id = ?", warehouseID)
+	}
+
+	if variantID := ctx.Query("variant_id"); variantID != "" {
+		query = query.Where("variant_id = ?", variantID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var movements []entity.InventoryMovement
+
+	if err := query.
+		Limit(limit).
+		Offset(offset).
+		Order("created_at desc").
+		Find(&movements).Error; err != nil {
+
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  movements,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// LowStock lists products at or below their reorder point, lowest stock
+// first. Products sold through variants are listed when any variant is low,
+// with their variants nested.
+func (h *ProductHandle) LowStock(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.Query("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+	if limit < 1 {
+		limit = 20
+	}
+
+	offset := (page - 1) * limit
+
+	query := h.db.Model(&entity.Product{}).Where(inventory.LowStockCondition)
+
+	status := ctx.Query("status")
+	if status != "" {
+		switch status {
+		case "active":
+			query = query.Where("products.disabled_at IS NULL")
+		case "inactive":
+			query = query.Where("products.disabled_at IS NOT NULL")
+		default:
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status value"})
+			return
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var products []entity.ProductWithCategory
+
+	if err := query.
+		Select(productWithCategorySelect).
+		Joins("LEFT JOIN categories ON products.category_id = categories.id").
+		Limit(limit).
+		Offset(offset).
+		Order("products.stock_quantity - products.reorder_point, products.name").
+		Scan(&products).Error; err != nil {
+
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := attachVariants(h.db, products); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  products,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
@@ -0,0 +1,492 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"github.com/gaspartv/api.ecommerce/src/internal/inventory"
+	"github.com/gaspartv/api.ecommerce/src/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (h *ProductHandle) CreateOption(ctx *gin.Context) {
+	var body entity.ProductOptionCreate
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", body.ProductID).First(&entity.Product{}).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.db.Where("product_id = ? AND name = ?", body.ProductID, body.Name).First(&entity.ProductOptionType{}).Error
+	if err == nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Option already exists"})
+		return
+	}
+
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	optionType := entity.NewProductOptionType(body)
+	if err := h.db.Create(optionType).Error; err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": optionType})
+}
+
+// DeleteOption removes an option type and its values while no live variant
+// uses them.
+func (h *ProductHandle) DeleteOption(ctx *gin.Context) {
+	idParam := ctx.Query("id")
+	if idParam == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var used int64
+		if err := tx.Table("product_variant_option_values").
+			Joins("JOIN product_option_values ON product_option_values.id = product_variant_option_values.option_value_id").
+			Joins("JOIN product_variants ON product_variants.id = product_variant_option_values.variant_id").
+			Where("product_option_values.option_type_id = ? AND product_variants.deleted_at IS NULL", idParam).
+			Count(&used).Error; err != nil {
+			return err
+		}
+
+		if used > 0 {
+			return &requestError{http.StatusConflict, "Option is used by variants"}
+		}
+
+		result := tx.Where("id = ?", idParam).Delete(&entity.ProductOptionType{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return &requestError{http.StatusNotFound, "Option not found"}
+		}
+
+		return nil
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Option deleted successfully"})
+}
+
+func (h *ProductHandle) CreateVariant(ctx *gin.Context) {
+	var body entity.ProductVariantCreate
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	var product entity.Product
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", body.ProductID).First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := middleware.CurrentUser(ctx)
+	variant := entity.NewProductVariant(body, product.Image)
+
+	// The opening stock goes through the ledger like a product's.
+	initialStock := variant.StockQuantity
+	variant.StockQuantity = 0
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkVariantSku(tx, body.Sku, ""); err != nil {
+			return err
+		}
+
+		values, err := resolveVariantOptions(tx, product.ID, body.OptionValueIDs)
+		if err != nil {
+			return err
+		}
+		variant.OptionValues = values
+
+		if err := tx.Create(variant).Error; err != nil {
+			return err
+		}
+
+		if initialStock == 0 {
+			return nil
+		}
+
+		movement := entity.NewInventoryMovement(product.ID, initialStock, entity.InventoryPurchase)
+		movement.VariantID = &variant.ID
+		movement.UserID = &user.ID
+		movement.Note = "Initial stock"
+		return inventory.Record(tx, movement)
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	variant.StockQuantity = initialStock
+	ctx.JSON(http.StatusCreated, gin.H{"data": variant})
+}
+
+func (h *ProductHandle) EditVariant(ctx *gin.Context) {
+	idParam := ctx.Query("id")
+	if idParam == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	var body entity.ProductVariantEdit
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	updates := map[string]interface{}{}
+
+	if body.Price != nil {
+		updates["price"] = *body.Price
+	}
+
+	if body.Sku != nil {
+		if err := checkVariantSku(h.db, *body.Sku, idParam); err != nil {
+			respondError(ctx, err)
+			return
+		}
+		updates["sku"] = *body.Sku
+	}
+
+	if len(updates) == 0 && body.StockQuantity == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+		return
+	}
+
+	user := middleware.CurrentUser(ctx)
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var locked entity.ProductVariant
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND deleted_at IS NULL", idParam).
+			First(&locked).Error; err != nil {
+
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &requestError{http.StatusNotFound, "Variant not found"}
+			}
+			return err
+		}
+
+		if len(updates) > 0 {
+			if err := tx.Model(&locked).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		if body.StockQuantity == nil {
+			return nil
+		}
+
+		// A stock value sent through edit is recorded as an adjustment for
+		// the difference, as product edit does.
+		delta := *body.StockQuantity - locked.StockQuantity
+		if delta == 0 {
+			return nil
+		}
+
+		movement := entity.NewInventoryMovement(locked.ProductID, delta, entity.InventoryAdjustment)
+		movement.VariantID = &locked.ID
+		movement.UserID = &user.ID
+		movement.Note = "Stock set through variant edit"
+		return inventory.Record(tx, movement)
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Variant updated successfully"})
+}
+
+func (h *ProductHandle) DeleteVariant(ctx *gin.Context) {
+	idParam := ctx.Query("id")
+	if idParam == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	result := h.db.Where("id = ? AND deleted_at IS NULL", idParam).Delete(&entity.ProductVariant{})
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Variant not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Variant deleted successfully"})
+}
+
+func (h *ProductHandle) DisableVariant(ctx *gin.Context) {
+	idParam := ctx.Query("id")
+	if idParam == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	var variant entity.ProductVariant
+
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", idParam).First(&variant).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Variant not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var newValue interface{}
+	if variant.DisabledAt == nil {
+		newValue = gorm.Expr("NOW()")
+	} else {
+		newValue = nil
+	}
+
+	if err := h.db.Model(&entity.ProductVariant{}).
+		Where("id = ?", idParam).
+		Update("disabled_at", newValue).Error; err != nil {
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	msg := "Variant enabled successfully"
+	status := "active"
+	if variant.DisabledAt == nil {
+		msg = "Variant disabled successfully"
+		status = "inactive"
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  status,
+		"message": msg,
+	})
+}
+
+func (h *ProductHandle) ChangeVariantImage(ctx *gin.Context) {
+	id := ctx.Query("id")
+	if id == "" {
+		ctx.JSON(400, gin.H{"error": "ID is required"})
+		return
+	}
+
+	header, err := ctx.FormFile("image")
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid file"})
+		return
+	}
+
+	var current entity.ProductVariant
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&current).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(404, gin.H{"error": "Variant not found"})
+			return
+		}
+		ctx.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	variants, err := storeImage(ctx.Request.Context(), h.db, h.store, h.env, header, "variants", true)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	result := h.db.Model(&entity.ProductVariant{}).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Update("image", variants[primaryRendition])
+
+	if result.Error != nil {
+		discardStored(ctx.Request.Context(), h.db, h.store, h.env, variants)
+		ctx.JSON(500, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		discardStored(ctx.Request.Context(), h.db, h.store, h.env, variants)
+		ctx.JSON(404, gin.H{"error": "Variant not found"})
+		return
+	}
+
+	discardImage(ctx.Request.Context(), h.db, h.store, h.env, current.Image, nil)
+
+	ctx.JSON(200, gin.H{
+		"message": "Image updated successfully",
+		"data": gin.H{
+			"image":          variants[primaryRendition],
+			"image_variants": variants,
+		},
+	})
+}
+
+// checkVariantSku rejects a SKU already used by a product or by another
+// variant, so a SKU always names exactly one sellable unit.
+func checkVariantSku(tx *gorm.DB, sku string, variantID string) error {
+	var count int64
+	if err := tx.Model(&entity.Product{}).Where("sku = ?", sku).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count == 0 {
+		if err := tx.Model(&entity.ProductVariant{}).
+			Where("sku = ? AND id <> ? AND deleted_at IS NULL", sku, variantID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+	}
+
+	if count > 0 {
+		return &requestError{http.StatusConflict, "SKU already exists"}
+	}
+
+	return nil
+}
+
+// checkProductSku is the other half of checkVariantSku: it rejects a product
+// SKU already used by a live variant.
+func checkProductSku(tx *gorm.DB, sku string) error {
+	var count int64
+	if err := tx.Model(&entity.ProductVariant{}).Where("sku = ?", sku).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return &requestError{http.StatusConflict, "SKU already exists"}
+	}
+
+	return nil
+}
+
+// resolveVariantOptions loads the option values of a new variant and checks
+// that they pick exactly one value of every option of the product and that
+// no live variant already uses the same combination.
+func resolveVariantOptions(tx *gorm.DB, productID string, valueIDs []string) ([]entity.ProductOptionValue, error) {
+	var optionTypes []entity.ProductOptionType
+	if err := tx.Where("product_id = ?", productID).Find(&optionTypes).Error; err != nil {
+		return nil, err
+	}
+
+	var values []entity.ProductOptionValue
+	if err := tx.Joins("OptionType").
+		Where("product_option_values.id IN ? AND \"OptionType\".product_id = ?", valueIDs, productID).
+		Find(&values).Error; err != nil {
+		return nil, err
+	}
+
+	picked := map[string]bool{}
+	for _, value := range values {
+		if picked[value.OptionTypeID] {
+			return nil, &requestError{http.StatusBadRequest, "Pick one value per option"}
+		}
+		picked[value.OptionTypeID] = true
+	}
+
+	if len(values) != len(valueIDs) || len(picked) != len(optionTypes) {
+		return nil, &requestError{http.StatusBadRequest, "Variant must pick one value of every product option"}
+	}
+
+	signature := variantSignature(values)
+
+	var existing []entity.ProductVariant
+	if err := tx.Preload("OptionValues").
+		Where("product_id = ? AND deleted_at IS NULL", productID).
+		Find(&existing).Error; err != nil {
+		return nil, err
+	}
+
+	for _, variant := range existing {
+		if variantSignature(variant.OptionValues) == signature {
+			return nil, &requestError{http.StatusConflict, "Variant with these options already exists"}
+		}
+	}
+
+	return values, nil
+}
+
+func variantSignature(values []entity.ProductOptionValue) string {
+	ids := make([]string, 0, len(values))
+	for _, value := range values {
+		ids = append(ids, value.ID)
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ",")
+}
+
+// attachVariants loads the options and live variants of a page of products
+// with two queries instead of one per product.
+func attachVariants(tx *gorm.DB, products []entity.ProductWithCategory) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+
+	var optionTypes []entity.ProductOptionType
+	if err := tx.Preload("Values", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Where("product_id IN ?", ids).
+		Order("position, created_at").
+		Find(&optionTypes).Error; err != nil {
+		return err
+	}
+
+	var variants []entity.ProductVariant
+	if err := tx.Preload("OptionValues.OptionType").
+		Where("product_id IN ? AND deleted_at IS NULL", ids).
+		Order("created_at").
+		Find(&variants).Error; err != nil {
+		return err
+	}
+
+	for i := range products {
+		products[i].Options = []entity.ProductOptionType{}
+		products[i].Variants = []entity.ProductVariant{}
+
+		for _, optionType := range optionTypes {
+			if optionType.ProductID == products[i].ID {
+				products[i].Options = append(products[i].Options, optionType)
+			}
+		}
+
+		for _, variant := range variants {
+			if variant.ProductID == products[i].ID {
+				products[i].Variants = append(products[i].Variants, variant)
+			}
+		}
+	}
+
+	return nil
+}
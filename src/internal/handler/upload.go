@@ -0,0 +1,297 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gaspartv/api.ecommerce/src/config"
+	"github.com/gaspartv/api.ecommerce/src/external/storage"
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"github.com/gaspartv/api.ecommerce/src/internal/media"
+	"github.com/gaspartv/api.ecommerce/src/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var uploadExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// uploadTargetDirs is where the renditions of each target kind are kept,
+// the same prefixes the change-image endpoints use.
+var uploadTargetDirs = map[string]string{
+	entity.UploadTargetCategory: "categories",
+	entity.UploadTargetProduct:  "products",
+	entity.UploadTargetUser:     "users",
+}
+
+type UploadHandle struct {
+	db    *gorm.DB
+	store storage.ObjectStore
+	env   *config.Env
+}
+
+func NewUploadHandler(db *gorm.DB, store storage.ObjectStore, env *config.Env) *UploadHandle {
+	return &UploadHandle{
+		db:    db,
+		store: store,
+		env:   env,
+	}
+}
+
+// Presign starts a direct upload: the client PUTs the file to the returned
+// URL, sending exactly the listed headers, and then calls Complete.
+func (h *UploadHandle) Presign(ctx *gin.Context) {
+	presigner, ok := h.store.(storage.Presigner)
+	if !ok {
+		ctx.JSON(http.StatusNotImplemented, gin.H{"error": "Direct uploads are not supported by the configured storage"})
+		return
+	}
+
+	var body entity.UploadPresign
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	if body.Size > int64(h.env.ImageMaxBytes) {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Image is too large"})
+		return
+	}
+
+	user := middleware.CurrentUser(ctx)
+
+	if body.Target == entity.UploadTargetUser && body.TargetID == "" {
+		body.TargetID = user.ID
+	}
+
+	if err := h.checkTarget(user, body.Target, body.TargetID); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	upload := entity.NewUpload(user.ID, body, h.env.UploadPresignTTL)
+	upload.Key = "uploads/" + upload.ID + uploadExtensions[upload.ContentType]
+
+	url, err := presigner.PresignPut(ctx.Request.Context(), upload.Key, upload.ContentType, upload.Size, h.env.UploadPresignTTL)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.db.Create(upload).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": gin.H{
+			"upload_id": upload.ID,
+			"url":       url,
+			"method":    http.MethodPut,
+			"headers": gin.H{
+				"Content-Type":   upload.ContentType,
+				"Content-Length": fmt.Sprint(upload.Size),
+			},
+			"expires_at": upload.ExpiresAt,
+		},
+	})
+}
+
+// Complete checks that the presigned upload arrived as signed, processes it
+// like an image sent to change-image and attaches it to its target. The raw
+// object and the replaced image are removed once the new one is attached.
+func (h *UploadHandle) Complete(ctx *gin.Context) {
+	var body entity.UploadComplete
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := middleware.CurrentUser(ctx)
+
+	var upload entity.Upload
+	if err := h.db.Where("id = ? AND user_id = ?", body.UploadID, user.ID).First(&upload).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if upload.CompletedAt != nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Upload already completed"})
+		return
+	}
+
+	info, err := h.store.Stat(ctx.Request.Context(), upload.Key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "File was not uploaded"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if info.Size != upload.Size {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Uploaded file does not match the presigned size"})
+		return
+	}
+
+	reader, _, err := h.store.Get(ctx.Request.Context(), upload.Key)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := media.Read(reader, int64(h.env.ImageMaxBytes))
+	reader.Close()
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	// Users keep a single image column, so only its rendition is stored.
+	primaryOnly := upload.Target == entity.UploadTargetUser
+	variants, err := storeImageData(ctx.Request.Context(), h.db, h.store, h.env, data, uploadTargetDirs[upload.Target], primaryOnly)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	var replacedImage string
+	var replacedVariants entity.ImageVariants
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entity.Upload{}).
+			Where("id = ? AND completed_at IS NULL", upload.ID).
+			Update("completed_at", gorm.Expr("NOW()"))
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return &requestError{http.StatusConflict, "Upload already completed"}
+		}
+
+		var err error
+		replacedImage, replacedVariants, err = attachUpload(tx, h.store, upload, variants, h.env.IMAGE_CATEGORY_DEFAULT_URL)
+		return err
+	})
+	if err != nil {
+		discardStored(ctx.Request.Context(), h.db, h.store, h.env, variants)
+		respondError(ctx, err)
+		return
+	}
+
+	if err := h.store.Delete(ctx.Request.Context(), upload.Key); err != nil {
+		log.Printf("Erro ao remover upload %s do armazenamento: %v", upload.Key, err)
+	}
+
+	discardImage(ctx.Request.Context(), h.db, h.store, h.env, replacedImage, replacedVariants)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Upload completed successfully",
+		"data": gin.H{
+			"image":          variants[primaryRendition],
+			"image_variants": variants,
+		},
+	})
+}
+
+// checkTarget applies the permissions of the matching change-image endpoint
+// and makes sure the target exists.
+func (h *UploadHandle) checkTarget(user *entity.User, target string, targetID string) error {
+	var model interface{}
+
+	switch target {
+	case entity.UploadTargetCategory, entity.UploadTargetProduct:
+		if !user.HasPermission(entity.PermissionCatalogWrite) {
+			return &requestError{http.StatusForbidden, "Permission denied"}
+		}
+
+		model = &entity.Category{}
+		if target == entity.UploadTargetProduct {
+			model = &entity.Product{}
+		}
+	case entity.UploadTargetUser:
+		if targetID != user.ID && !user.HasPermission(entity.PermissionUsersManage) {
+			return &requestError{http.StatusForbidden, "Permission denied"}
+		}
+
+		model = &entity.User{}
+	}
+
+	if targetID == "" {
+		return &requestError{http.StatusBadRequest, "Target ID is required"}
+	}
+
+	var count int64
+	if err := h.db.Model(model).Where("id = ? AND deleted_at IS NULL", targetID).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return &requestError{http.StatusNotFound, "Target not found"}
+	}
+
+	return nil
+}
+
+// attachUpload points the upload target at the new image and returns the
+// image it replaced. Users only keep the primary rendition, like
+// change-profile-image, and products get it as their primary gallery
+// image, like change-image.
+func attachUpload(tx *gorm.DB, store storage.ObjectStore, upload entity.Upload, variants entity.ImageVariants, defaultImage string) (string, entity.ImageVariants, error) {
+	var model interface{}
+	updates := map[string]interface{}{
+		"image":          variants[primaryRendition],
+		"image_variants": variants,
+	}
+
+	switch upload.Target {
+	case entity.UploadTargetCategory:
+		model = &entity.Category{}
+	case entity.UploadTargetProduct:
+		model = &entity.Product{}
+	case entity.UploadTargetUser:
+		model = &entity.User{}
+		delete(updates, "image_variants")
+	default:
+		return "", nil, fmt.Errorf("unknown upload target %q", upload.Target)
+	}
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND deleted_at IS NULL", upload.TargetID).
+		First(model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil, &requestError{http.StatusNotFound, "Target not found"}
+		}
+		return "", nil, err
+	}
+
+	var image string
+	var replaced entity.ImageVariants
+	switch current := model.(type) {
+	case *entity.Category:
+		image, replaced = current.Image, current.ImageVariants
+	case *entity.Product:
+		return replacePrimaryImage(tx, store, current, variants, defaultImage)
+	case *entity.User:
+		image = current.Image
+	}
+
+	if err := tx.Model(model).Updates(updates).Error; err != nil {
+		return "", nil, err
+	}
+
+	return image, replaced, nil
+}
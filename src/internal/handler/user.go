@@ -1,26 +1,37 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/gaspartv/api.ecommerce/src/config"
+	"github.com/gaspartv/api.ecommerce/src/external/mailer"
+	"github.com/gaspartv/api.ecommerce/src/external/storage"
 	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"github.com/gaspartv/api.ecommerce/src/internal/middleware"
+	"github.com/gaspartv/api.ecommerce/src/internal/password"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type UserHandle struct {
-	db  *gorm.DB
-	r2  *s3.Client
-	env *config.Env
+	db        *gorm.DB
+	store     storage.ObjectStore
+	mailer    mailer.Mailer
+	passwords *password.Policy
+	env       *config.Env
 }
 
-func NewUserHandler(db *gorm.DB, r2 *s3.Client, env *config.Env) *UserHandle {
+func NewUserHandler(db *gorm.DB, store storage.ObjectStore, mailer mailer.Mailer, passwords *password.Policy, env *config.Env) *UserHandle {
 	return &UserHandle{
-		db:  db,
-		r2:  r2,
-		env: env,
+		db:        db,
+		store:     store,
+		mailer:    mailer,
+		passwords: passwords,
+		env:       env,
 	}
 }
 
@@ -32,16 +43,374 @@ func (h *UserHandle) Create(ctx *gin.Context) {
 		return
 	}
 
+	if !h.checkPassword(ctx, "password", userCreate.PasswordHash) {
+		return
+	}
+
 	user, err := entity.NewUser(userCreate, h.env)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	err = h.db.Where("email = ? AND deleted_at IS NULL", user.Email).First(&entity.User{}).Error
+	if err == nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Email already in use"})
+		return
+	}
+
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var role entity.Role
+	if err := h.db.Where("name = ?", entity.RoleCustomer).First(&role).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	user.RoleID = role.ID
+
 	if err := h.db.Create(&user).Error; err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	h.sendVerificationEmailAsync(*user)
+
 	ctx.JSON(http.StatusCreated, gin.H{"data": user})
 }
+
+// userOrderColumns are the columns the user list can be sorted by;
+// order_by goes into the ORDER BY clause, so nothing else is accepted.
+var userOrderColumns = map[string]bool{
+	"name":       true,
+	"email":      true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+func (h *UserHandle) List(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.Query("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+	if limit < 1 {
+		limit = 20
+	}
+
+	orderBy := ctx.Query("order_by")
+	if !userOrderColumns[orderBy] {
+		orderBy = "updated_at"
+	}
+
+	orderDir := ctx.Query("order_dir")
+	if orderDir != "asc" && orderDir != "desc" {
+		orderDir = "desc"
+	}
+
+	offset := (page - 1) * limit
+
+	query := h.db.Model(&entity.User{}).Where("deleted_at IS NULL")
+
+	search := ctx.Query("search")
+	if search != "" {
+		like := "%" + search + "%"
+		query = query.Where("name ILIKE ? OR email ILIKE ?", like, like)
+	}
+
+	status := ctx.Query("status")
+	if status != "" {
+		switch status {
+		case "active":
+			query = query.Where("disabled_at IS NULL")
+		case "inactive":
+			query = query.Where("disabled_at IS NOT NULL")
+		default:
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status value"})
+			return
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var users []entity.User
+
+	if err := query.
+		Preload("Role").
+		Limit(limit).
+		Offset(offset).
+		Order(fmt.Sprintf("%s %s", orderBy, orderDir)).
+		Find(&users).Error; err != nil {
+
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  users,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+func (h *UserHandle) Edit(ctx *gin.Context) {
+	idParam, ok := targetUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var body entity.UserEdit
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	var current entity.User
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", idParam).First(&current).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	updates := map[string]interface{}{}
+
+	if body.Name != nil && *body.Name != current.Name {
+		updates["name"] = *body.Name
+	}
+
+	if body.Email != nil {
+		newEmail := strings.ToLower(strings.TrimSpace(*body.Email))
+		if newEmail != current.Email {
+			err := h.db.Where("email = ? AND id <> ? AND deleted_at IS NULL", newEmail, idParam).First(&entity.User{}).Error
+			if err == nil {
+				ctx.JSON(http.StatusConflict, gin.H{"error": "Email already in use"})
+				return
+			}
+
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+
+			updates["email"] = newEmail
+			updates["email_verified_at"] = nil
+		}
+	}
+
+	if len(updates) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+		return
+	}
+
+	if err := h.db.Model(&entity.User{}).Where("id = ? AND deleted_at IS NULL", idParam).Updates(updates).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if email, changed := updates["email"].(string); changed {
+		current.Email = email
+		h.sendVerificationEmailAsync(current)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "User updated successfully"})
+}
+
+func (h *UserHandle) Delete(ctx *gin.Context) {
+	idParam := ctx.Query("id")
+	if idParam == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	result := h.db.Where("id = ? AND deleted_at IS NULL", idParam).Delete(&entity.User{})
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := revokeUserSessions(h.db, idParam); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
+}
+
+func (h *UserHandle) Disable(ctx *gin.Context) {
+	idParam := ctx.Query("id")
+	if idParam == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	var user entity.User
+
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", idParam).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var newValue interface{}
+	if user.DisabledAt == nil {
+		newValue = gorm.Expr("NOW()")
+	} else {
+		newValue = nil
+	}
+
+	if err := h.db.Model(&entity.User{}).
+		Where("id = ?", idParam).
+		Update("disabled_at", newValue).Error; err != nil {
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	msg := "User enabled successfully"
+	status := "active"
+	if user.DisabledAt == nil {
+		msg = "User disabled successfully"
+		status = "inactive"
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  status,
+		"message": msg,
+	})
+}
+
+func (h *UserHandle) ChangeProfileImage(ctx *gin.Context) {
+	id, ok := targetUserID(ctx)
+	if !ok {
+		return
+	}
+
+	header, err := ctx.FormFile("image")
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid file"})
+		return
+	}
+
+	var current entity.User
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&current).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(404, gin.H{"error": "User not found"})
+			return
+		}
+		ctx.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	variants, err := storeImage(ctx.Request.Context(), h.db, h.store, h.env, header, "users", true)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	result := h.db.Model(&entity.User{}).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Update("image", variants[primaryRendition])
+
+	if result.Error != nil {
+		discardStored(ctx.Request.Context(), h.db, h.store, h.env, variants)
+		ctx.JSON(500, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		discardStored(ctx.Request.Context(), h.db, h.store, h.env, variants)
+		ctx.JSON(404, gin.H{"error": "User not found"})
+		return
+	}
+
+	discardImage(ctx.Request.Context(), h.db, h.store, h.env, current.Image, nil)
+
+	ctx.JSON(200, gin.H{
+		"message": "Image updated successfully",
+		"data": gin.H{
+			"image":          variants[primaryRendition],
+			"image_variants": variants,
+		},
+	})
+}
+
+func (h *UserHandle) ChangeRole(ctx *gin.Context) {
+	idParam := ctx.Query("id")
+	if idParam == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	var body entity.UserChangeRole
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	var role entity.Role
+	if err := h.db.Where("name = ?", body.Role).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := h.db.Model(&entity.User{}).
+		Where("id = ? AND deleted_at IS NULL", idParam).
+		Update("role_id", role.ID)
+
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Role updated successfully"})
+}
+
+// targetUserID resolves which user a self-service route acts on. Without an
+// id it is the caller; acting on someone else requires users:manage.
+func targetUserID(ctx *gin.Context) (string, bool) {
+	current := middleware.CurrentUser(ctx)
+	if current == nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization token is required"})
+		return "", false
+	}
+
+	id := ctx.Query("id")
+	if id == "" || id == current.ID {
+		return current.ID, true
+	}
+
+	if !current.HasPermission(entity.PermissionUsersManage) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+		return "", false
+	}
+
+	return id, true
+}
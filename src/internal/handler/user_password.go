@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gaspartv/api.ecommerce/src/external/mailer"
+	"github.com/gaspartv/api.ecommerce/src/internal/auth"
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"github.com/gaspartv/api.ecommerce/src/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (h *UserHandle) ForgotPassword(ctx *gin.Context) {
+	var body entity.UserForgotPassword
+
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	msg := "If the email is registered, a password reset link has been sent"
+
+	var user entity.User
+	email := strings.ToLower(strings.TrimSpace(body.Email))
+
+	err := h.db.Where("email = ? AND deleted_at IS NULL AND disabled_at IS NULL", email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusOK, gin.H{"message": msg})
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, tokenHash, err := auth.NewOpaqueToken()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		// Only the most recent link stays usable.
+		if err := tx.Model(&entity.PasswordReset{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", gorm.Expr("NOW()")).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(entity.NewPasswordReset(user.ID, tokenHash, h.env.PasswordResetTTL)).Error
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	link := fmt.Sprintf("%s?token=%s", h.env.PasswordResetURL, url.QueryEscape(token))
+
+	message := mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nWe received a request to reset your password. Open the link below to choose a new one:\n\n%s\n\nThe link expires in %s. If you did not ask for this, ignore this email.",
+			user.Name,
+			link,
+			h.env.PasswordResetTTL,
+		),
+	}
+
+	// Sent in the background, so a mailer failure or delay does not tell a
+	// registered email apart from an unknown one.
+	go func() {
+		if err := h.mailer.Send(message); err != nil {
+			log.Println("Erro ao enviar email de redefinição de senha:", err)
+		}
+	}()
+
+	ctx.JSON(http.StatusOK, gin.H{"message": msg})
+}
+
+func (h *UserHandle) ResetPassword(ctx *gin.Context) {
+	var body entity.UserResetPassword
+
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.checkPassword(ctx, "password", body.Password) {
+		return
+	}
+
+	hash, err := entity.HashPassword(body.Password)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	errInvalidToken := errors.New("invalid reset token")
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		var reset entity.PasswordReset
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND used_at IS NULL AND expires_at > NOW()", auth.HashOpaqueToken(body.Token)).
+			First(&reset).Error; err != nil {
+
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errInvalidToken
+			}
+			return err
+		}
+
+		if err := tx.Model(&reset).Update("used_at", gorm.Expr("NOW()")).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&entity.User{}).
+			Where("id = ? AND deleted_at IS NULL", reset.UserID).
+			Update("password_hash", hash)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return errInvalidToken
+		}
+
+		return revokeUserSessions(tx, reset.UserID)
+	})
+	if err != nil {
+		if errors.Is(err, errInvalidToken) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired token"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
+}
+
+func (h *UserHandle) ChangePassword(ctx *gin.Context) {
+	user := middleware.CurrentUser(ctx)
+
+	var body entity.UserChangePassword
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(body.CurrentPassword)); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+		return
+	}
+
+	if !h.checkPassword(ctx, "new_password", body.NewPassword) {
+		return
+	}
+
+	hash, err := entity.HashPassword(body.NewPassword)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Every session is revoked, including the caller's, and a fresh one is
+	// returned so the current device stays signed in.
+	var tokens *entity.SessionTokens
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.User{}).
+			Where("id = ?", user.ID).
+			Update("password_hash", hash).Error; err != nil {
+			return err
+		}
+
+		if err := revokeUserSessions(tx, user.ID); err != nil {
+			return err
+		}
+
+		tokens, err = h.issueSession(tx, ctx, user.ID)
+		return err
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Password changed successfully",
+		"data":    tokens,
+	})
+}
+
+func (h *UserHandle) checkPassword(ctx *gin.Context, field string, value string) bool {
+	violations := h.passwords.Validate(field, value)
+	if len(violations) == 0 {
+		return true
+	}
+
+	ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error":  "Password does not meet the password policy",
+		"fields": violations,
+	})
+	return false
+}
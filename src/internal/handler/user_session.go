@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gaspartv/api.ecommerce/src/internal/auth"
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// dummyPasswordHash is compared against when the email is unknown, so a
+// failed login takes as long whether or not the account exists.
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, err := entity.HashPassword("dummy password for unknown accounts")
+	if err != nil {
+		panic(err)
+	}
+	return []byte(hash)
+})
+
+func (h *UserHandle) Login(ctx *gin.Context) {
+	var body entity.UserLogin
+
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user entity.User
+	email := strings.ToLower(strings.TrimSpace(body.Email))
+
+	if err := h.db.Where("email = ? AND deleted_at IS NULL", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(body.Password))
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(body.Password)); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		return
+	}
+
+	if user.DisabledAt != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "User is disabled"})
+		return
+	}
+
+	var tokens *entity.SessionTokens
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if cartToken := ctx.GetHeader(CartTokenHeader); cartToken != "" {
+			if err := mergeGuestCart(tx, cartToken, user.ID); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		tokens, err = h.issueSession(tx, ctx, user.ID)
+		return err
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": tokens})
+}
+
+func (h *UserHandle) Refresh(ctx *gin.Context) {
+	var body entity.SessionRefresh
+
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	var session entity.Session
+	if err := h.db.Where("token_hash = ?", auth.HashOpaqueToken(body.RefreshToken)).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if session.RevokedAt != nil {
+		// A rotated token being replayed means it leaked, so every session of
+		// the user is revoked and they have to sign in again.
+		if err := revokeUserSessions(h.db, session.UserID); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token expired"})
+		return
+	}
+
+	var user entity.User
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", session.UserID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if user.DisabledAt != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "User is disabled"})
+		return
+	}
+
+	var tokens *entity.SessionTokens
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entity.Session{}).
+			Where("id = ? AND revoked_at IS NULL", session.ID).
+			Update("revoked_at", gorm.Expr("NOW()"))
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return auth.ErrInvalidToken
+		}
+
+		var err error
+		tokens, err = h.issueSession(tx, ctx, user.ID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidToken) {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": tokens})
+}
+
+func (h *UserHandle) Logout(ctx *gin.Context) {
+	var body entity.SessionRefresh
+
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.db.Model(&entity.Session{}).
+		Where("token_hash = ? AND revoked_at IS NULL", auth.HashOpaqueToken(body.RefreshToken)).
+		Update("revoked_at", gorm.Expr("NOW()")).Error; err != nil {
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+func (h *UserHandle) issueSession(tx *gorm.DB, ctx *gin.Context, userID string) (*entity.SessionTokens, error) {
+	refreshToken, refreshHash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := entity.NewSession(userID, refreshHash, h.env.JwtRefreshTTL)
+	session.UserAgent = truncate(ctx.Request.UserAgent(), 255)
+	session.IP = ctx.ClientIP()
+
+	if err := tx.Create(session).Error; err != nil {
+		return nil, err
+	}
+
+	accessToken, accessExpiresAt, err := auth.NewAccessToken(userID, session.ID, h.env)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.SessionTokens{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: session.ExpiresAt,
+		TokenType:        "Bearer",
+	}, nil
+}
+
+func revokeUserSessions(tx *gorm.DB, userID string) error {
+	return tx.Model(&entity.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", gorm.Expr("NOW()")).Error
+}
+
+// truncate cuts value to at most max characters, never splitting one.
+func truncate(value string, max int) string {
+	if utf8.RuneCountInString(value) <= max {
+		return value
+	}
+
+	runes := []rune(value)
+	return string(runes[:max])
+}
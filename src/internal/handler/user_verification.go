@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gaspartv/api.ecommerce/src/external/mailer"
+	"github.com/gaspartv/api.ecommerce/src/internal/auth"
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func (h *UserHandle) VerifyEmail(ctx *gin.Context) {
+	token := ctx.Query("token")
+	if token == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Token parameter is required"})
+		return
+	}
+
+	claims, err := auth.ParseActionToken(token, auth.PurposeEmailVerification, h.env)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired token"})
+		return
+	}
+
+	var user entity.User
+	if err := h.db.Where("id = ? AND email = ? AND deleted_at IS NULL", claims.Subject, claims.Email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired token"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if user.EmailVerifiedAt != nil {
+		ctx.JSON(http.StatusOK, gin.H{"message": "Email already verified"})
+		return
+	}
+
+	if err := h.db.Model(&entity.User{}).
+		Where("id = ?", user.ID).
+		Update("email_verified_at", gorm.Expr("NOW()")).Error; err != nil {
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
+}
+
+func (h *UserHandle) ResendVerification(ctx *gin.Context) {
+	var body entity.UserResendVerification
+
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The response is the same whether or not the address exists so the
+	// endpoint cannot be used to discover registered emails.
+	msg := "If the email is registered and unverified, a verification link has been sent"
+
+	var user entity.User
+	email := strings.ToLower(strings.TrimSpace(body.Email))
+
+	err := h.db.Where("email = ? AND deleted_at IS NULL AND email_verified_at IS NULL", email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusOK, gin.H{"message": msg})
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.sendVerificationEmail(&user); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": msg})
+}
+
+func (h *UserHandle) sendVerificationEmail(user *entity.User) error {
+	token, err := auth.NewActionToken(user.ID, user.Email, auth.PurposeEmailVerification, h.env.EmailVerificationTTL, h.env)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/users/verify-email?token=%s", strings.TrimRight(h.env.AppURL, "/"), url.QueryEscape(token))
+
+	return h.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your email",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nConfirm your email address by opening the link below:\n\n%s\n\nThe link expires in %s.",
+			user.Name,
+			link,
+			h.env.EmailVerificationTTL,
+		),
+	})
+}
+
+// sendVerificationEmailAsync is used after the user row is already saved,
+// where a mail failure should not turn a successful request into an error.
+func (h *UserHandle) sendVerificationEmailAsync(user entity.User) {
+	go func() {
+		if err := h.sendVerificationEmail(&user); err != nil {
+			log.Println("Erro ao enviar email de verificação:", err)
+		}
+	}()
+}
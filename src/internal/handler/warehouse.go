@@ -0,0 +1,334 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gaspartv/api.ecommerce/src/config"
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"github.com/gaspartv/api.ecommerce/src/internal/inventory"
+	"github.com/gaspartv/api.ecommerce/src/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WarehouseHandle struct {
+	db  *gorm.DB
+	env *config.Env
+}
+
+func NewWarehouseHandler(db *gorm.DB, env *config.Env) *WarehouseHandle {
+	return &WarehouseHandle{
+		db:  db,
+		env: env,
+	}
+}
+
+func (h *WarehouseHandle) Create(ctx *gin.Context) {
+	var body entity.WarehouseCreate
+
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	warehouse := entity.NewWarehouse(body)
+
+	err := h.db.Where("code = ? AND deleted_at IS NULL", warehouse.Code).First(&entity.Warehouse{}).Error
+	if err == nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Warehouse code already exists"})
+		return
+	}
+
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.db.Create(warehouse).Error; err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": warehouse})
+}
+
+func (h *WarehouseHandle) List(ctx *gin.Context) {
+	query := h.db.Model(&entity.Warehouse{}).Where("deleted_at IS NULL")
+
+	status := ctx.Query("status")
+	if status != "" {
+		switch status {
+		case "active":
+			query = query.Where("disabled_at IS NULL")
+		case "inactive":
+			query = query.Where("disabled_at IS NOT NULL")
+		default:
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status value"})
+			return
+		}
+	}
+
+	var warehouses []entity.Warehouse
+	if err := query.Order("priority, created_at").Find(&warehouses).Error; err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": warehouses})
+}
+
+func (h *WarehouseHandle) Edit(ctx *gin.Context) {
+	idParam := ctx.Query("id")
+	if idParam == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	var body entity.WarehouseEdit
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	var current entity.Warehouse
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", idParam).First(&current).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Warehouse not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	updates := map[string]interface{}{}
+
+	if body.Name != nil {
+		updates["name"] = *body.Name
+	}
+
+	if body.Code != nil && *body.Code != current.Code {
+		err := h.db.Where("code = ? AND id <> ? AND deleted_at IS NULL", *body.Code, idParam).First(&entity.Warehouse{}).Error
+		if err == nil {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Warehouse code already exists"})
+			return
+		}
+
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		updates["code"] = *body.Code
+	}
+
+	if body.Latitude != nil {
+		updates["latitude"] = *body.Latitude
+	}
+
+	if body.Longitude != nil {
+		updates["longitude"] = *body.Longitude
+	}
+
+	if body.Priority != nil {
+		updates["priority"] = *body.Priority
+	}
+
+	if len(updates) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+		return
+	}
+
+	if err := h.db.Model(&entity.Warehouse{}).Where("id = ? AND deleted_at IS NULL", idParam).Updates(updates).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Warehouse updated successfully"})
+}
+
+// Delete only removes warehouses without stock; transfer the units out first.
+func (h *WarehouseHandle) Delete(ctx *gin.Context) {
+	idParam := ctx.Query("id")
+	if idParam == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var stocked int64
+		if err := tx.Model(&entity.WarehouseStock{}).
+			Where("warehouse_id = ? AND quantity > 0", idParam).
+			Count(&stocked).Error; err != nil {
+			return err
+		}
+
+		if stocked > 0 {
+			return &requestError{http.StatusConflict, "Warehouse still holds stock"}
+		}
+
+		result := tx.Where("id = ? AND deleted_at IS NULL", idParam).Delete(&entity.Warehouse{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return &requestError{http.StatusNotFound, "Warehouse not found"}
+		}
+
+		return nil
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Warehouse deleted successfully"})
+}
+
+func (h *WarehouseHandle) Disable(ctx *gin.Context) {
+	idParam := ctx.Query("id")
+	if idParam == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	var warehouse entity.Warehouse
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", idParam).First(&warehouse).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Warehouse not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var newValue interface{}
+	if warehouse.DisabledAt == nil {
+		newValue = gorm.Expr("NOW()")
+	} else {
+		newValue = nil
+	}
+
+	if err := h.db.Model(&entity.Warehouse{}).
+		Where("id = ?", idParam).
+		Update("disabled_at", newValue).Error; err != nil {
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	msg := "Warehouse enabled successfully"
+	status := "active"
+	if warehouse.DisabledAt == nil {
+		msg = "Warehouse disabled successfully"
+		status = "inactive"
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  status,
+		"message": msg,
+	})
+}
+
+// Transfer moves units of a product between two warehouses as a pair of
+// ledger movements, leaving the product total unchanged.
+func (h *WarehouseHandle) Transfer(ctx *gin.Context) {
+	var body entity.WarehouseTransfer
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := middleware.CurrentUser(ctx)
+
+	var movements []*entity.InventoryMovement
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var product entity.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND deleted_at IS NULL", body.ProductID).
+			First(&product).Error; err != nil {
+
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &requestError{http.StatusNotFound, "Product not found"}
+			}
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&entity.Warehouse{}).
+			Where("id IN ? AND deleted_at IS NULL AND disabled_at IS NULL", []string{body.FromWarehouseID, body.ToWarehouseID}).
+			Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count != 2 {
+			return &requestError{http.StatusNotFound, "Warehouse not found"}
+		}
+
+		out := entity.NewInventoryMovement(product.ID, -body.Quantity, entity.InventoryTransfer)
+		out.WarehouseID = &body.FromWarehouseID
+		in := entity.NewInventoryMovement(product.ID, body.Quantity, entity.InventoryTransfer)
+		in.WarehouseID = &body.ToWarehouseID
+
+		for _, movement := range []*entity.InventoryMovement{out, in} {
+			movement.UserID = &user.ID
+			movement.Note = body.Note
+			if err := inventory.Record(tx, movement); err != nil {
+				return err
+			}
+		}
+
+		movements = []*entity.InventoryMovement{out, in}
+		return nil
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": movements})
+}
+
+// Stock lists the per-warehouse quantities of a product. The total is their
+// sum, shown next to the product's stock_quantity so any drift between the
+// two is visible.
+func (h *WarehouseHandle) Stock(ctx *gin.Context) {
+	productID := ctx.Query("product_id")
+	if productID == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Product ID parameter is required"})
+		return
+	}
+
+	var product entity.Product
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", productID).First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var stocks []entity.WarehouseStock
+	if err := h.db.Preload("Warehouse").
+		Where("product_id = ?", productID).
+		Order("quantity desc").
+		Find(&stocks).Error; err != nil {
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	total := 0
+	for _, stock := range stocks {
+		total += stock.Quantity
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":           stocks,
+		"total":          total,
+		"stock_quantity": product.StockQuantity,
+	})
+}
@@ -0,0 +1,432 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gaspartv/api.ecommerce/src/config"
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"github.com/gaspartv/api.ecommerce/src/internal/inventory"
+	"github.com/gaspartv/api.ecommerce/src/internal/slug"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// progressEvery is how many rows are applied between progress updates.
+const progressEvery = 25
+
+// item is a validated row ready to be written. Fields left blank on an
+// existing product keep their current value.
+type item struct {
+	line     int
+	product  entity.ProductCreate
+	set      map[string]bool
+	existing *entity.Product
+}
+
+// Run validates rows and, when every row is valid and the job is not
+// validate-only, creates or updates the products by SKU. Progress and the
+// outcome are written to the job row as it goes.
+func Run(db *gorm.DB, env *config.Env, job *entity.ProductImport, rows []Row) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Erro na importação de produtos %s: %v", job.ID, r)
+			finish(db, job, entity.ProductImportFailed, "Import stopped unexpectedly")
+		}
+	}()
+
+	job.Status = entity.ProductImportRunning
+	job.TotalRows = len(rows)
+	if err := db.Model(job).Updates(map[string]interface{}{
+		"status":     job.Status,
+		"total_rows": job.TotalRows,
+	}).Error; err != nil {
+		log.Printf("Erro ao atualizar importação de produtos %s: %v", job.ID, err)
+	}
+
+	items, rowErrors, err := Validate(db, rows)
+	if err != nil {
+		finish(db, job, entity.ProductImportFailed, err.Error())
+		return
+	}
+
+	if len(rowErrors) > 0 {
+		job.Errors = rowErrors
+		finish(db, job, entity.ProductImportFailed, fmt.Sprintf("%d problems found, nothing was imported", len(rowErrors)))
+		return
+	}
+
+	if job.ValidateOnly {
+		finish(db, job, entity.ProductImportValidated, fmt.Sprintf("%d rows are valid", len(items)))
+		return
+	}
+
+	for i, item := range items {
+		created, err := apply(db, env, job.UserID, item)
+		switch {
+		case err != nil:
+			job.Errors = append(job.Errors, entity.ProductImportError{Line: item.line, Sku: item.product.Sku, Message: err.Error()})
+		case created:
+			job.CreatedCount++
+		default:
+			job.UpdatedCount++
+		}
+
+		job.ProcessedRows = i + 1
+		if job.ProcessedRows%progressEvery == 0 {
+			if err := db.Model(job).Updates(map[string]interface{}{
+				"processed_rows": job.ProcessedRows,
+				"created_count":  job.CreatedCount,
+				"updated_count":  job.UpdatedCount,
+			}).Error; err != nil {
+				log.Printf("Erro ao atualizar importação de produtos %s: %v", job.ID, err)
+			}
+		}
+	}
+
+	message := fmt.Sprintf("%d created, %d updated", job.CreatedCount, job.UpdatedCount)
+	if len(job.Errors) > 0 {
+		message += fmt.Sprintf(", %d failed", len(job.Errors))
+	}
+	finish(db, job, entity.ProductImportCompleted, message)
+}
+
+func finish(db *gorm.DB, job *entity.ProductImport, status string, message string) {
+	now := time.Now()
+	job.Status = status
+	job.Message = message
+	job.FinishedAt = &now
+
+	if err := db.Model(job).Updates(map[string]interface{}{
+		"status":         job.Status,
+		"message":        job.Message,
+		"finished_at":    job.FinishedAt,
+		"errors":         job.Errors,
+		"processed_rows": job.ProcessedRows,
+		"created_count":  job.CreatedCount,
+		"updated_count":  job.UpdatedCount,
+	}).Error; err != nil {
+		log.Printf("Erro ao finalizar importação de produtos %s: %v", job.ID, err)
+	}
+}
+
+// Validate checks every row before anything is written: required fields,
+// numbers, categories (by name or ID), SKUs and names repeated in the file,
+// and names already used by another product. Columns use the JSON names of
+// entity.ProductCreate, plus "category" for a category name.
+func Validate(db *gorm.DB, rows []Row) ([]item, entity.ProductImportErrors, error) {
+	rowErrors := entity.ProductImportErrors{}
+
+	var categories []entity.Category
+	if err := db.Where("deleted_at IS NULL").Find(&categories).Error; err != nil {
+		return nil, nil, err
+	}
+
+	categoryByName := map[string]string{}
+	categoryByID := map[string]bool{}
+	for _, category := range categories {
+		categoryByName[strings.ToLower(category.Name)] = category.ID
+		categoryByID[category.ID] = true
+	}
+
+	skus := make([]string, 0, len(rows))
+	names := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if sku := row.Values["sku"]; sku != "" {
+			skus = append(skus, sku)
+		}
+		if name := row.Values["name"]; name != "" {
+			names = append(names, name)
+		}
+	}
+
+	// Unscoped, since SKUs and names stay unique among deleted products.
+	var known []entity.Product
+	if err := db.Unscoped().Where("sku IN ? OR name IN ?", skus, names).Find(&known).Error; err != nil {
+		return nil, nil, err
+	}
+
+	bySku := map[string]*entity.Product{}
+	byName := map[string]*entity.Product{}
+	for i := range known {
+		bySku[known[i].Sku] = &known[i]
+		byName[known[i].Name] = &known[i]
+	}
+
+	// A SKU names one sellable unit, so variant SKUs are taken too.
+	var variantSkus []string
+	if err := db.Model(&entity.ProductVariant{}).Where("sku IN ?", skus).Pluck("sku", &variantSkus).Error; err != nil {
+		return nil, nil, err
+	}
+
+	variantSku := map[string]bool{}
+	for _, sku := range variantSkus {
+		variantSku[sku] = true
+	}
+
+	items := make([]item, 0, len(rows))
+	seenSku := map[string]int{}
+	seenName := map[string]int{}
+
+	for _, row := range rows {
+		fail := func(format string, args ...interface{}) {
+			rowErrors = append(rowErrors, entity.ProductImportError{
+				Line:    row.Line,
+				Sku:     row.Values["sku"],
+				Message: fmt.Sprintf(format, args...),
+			})
+		}
+		failures := len(rowErrors)
+
+		it := item{line: row.Line, set: map[string]bool{}}
+		product := &it.product
+		values := row.Values
+
+		product.Sku = values["sku"]
+		if product.Sku == "" {
+			fail("sku is required")
+		} else if len(product.Sku) > 100 {
+			fail("sku must have at most 100 characters")
+		} else if line, ok := seenSku[product.Sku]; ok {
+			fail("sku repeats line %d", line)
+		} else if variantSku[product.Sku] {
+			fail("sku is already used by a variant")
+		} else {
+			seenSku[product.Sku] = row.Line
+
+			if existing := bySku[product.Sku]; existing != nil {
+				if existing.DeletedAt.Valid {
+					fail("sku belongs to a deleted product")
+				}
+				it.existing = existing
+			}
+		}
+
+		isNew := it.existing == nil
+		required := func(column string) bool {
+			if values[column] != "" {
+				it.set[column] = true
+				return true
+			}
+			if isNew {
+				fail("%s is required", column)
+			}
+			return false
+		}
+
+		if required("name") {
+			product.Name = values["name"]
+			if len(product.Name) > 255 {
+				fail("name must have at most 255 characters")
+			} else if line, ok := seenName[product.Name]; ok {
+				fail("name repeats line %d", line)
+			} else {
+				seenName[product.Name] = row.Line
+				if other := byName[product.Name]; other != nil && other.Sku != product.Sku {
+					fail("name is already used by product %s", other.Sku)
+				}
+			}
+		}
+
+		if required("description") {
+			product.Description = values["description"]
+			if len(product.Description) > 510 {
+				fail("description must have at most 510 characters")
+			}
+		}
+
+		if required("price") {
+			price, err := parseDecimal(values["price"])
+			if err != nil || price <= 0 {
+				fail("price must be a positive number")
+			}
+			product.Price = price
+		}
+
+		switch {
+		case values["category_id"] != "":
+			it.set["category_id"] = true
+			product.CategoryID = values["category_id"]
+			if !categoryByID[product.CategoryID] {
+				fail("category %s not found", product.CategoryID)
+			}
+		case values["category"] != "":
+			it.set["category_id"] = true
+			product.CategoryID = categoryByName[strings.ToLower(values["category"])]
+			if product.CategoryID == "" {
+				fail("category %q not found", values["category"])
+			}
+		case isNew:
+			fail("category is required")
+		}
+
+		if values["stock_quantity"] != "" {
+			it.set["stock_quantity"] = true
+			stock, err := strconv.Atoi(values["stock_quantity"])
+			if err != nil || stock < 0 {
+				fail("stock_quantity must be a whole number of at least 0")
+			}
+			product.StockQuantity = stock
+		}
+
+		if values["weight"] != "" {
+			it.set["weight"] = true
+			weight, err := parseDecimal(values["weight"])
+			if err != nil || weight < 0 {
+				fail("weight must be a number of at least 0")
+			}
+			product.Weight = weight
+		}
+
+		if values["dimensions"] != "" {
+			it.set["dimensions"] = true
+			product.Dimensions = values["dimensions"]
+			if len(product.Dimensions) > 100 {
+				fail("dimensions must have at most 100 characters")
+			}
+		}
+
+		if values["is_featured"] != "" {
+			it.set["is_featured"] = true
+			featured, err := parseBool(values["is_featured"])
+			if err != nil {
+				fail("is_featured must be true or false")
+			}
+			product.IsFeatured = featured
+		}
+
+		for _, column := range []string{"reorder_point", "reorder_quantity"} {
+			if values[column] == "" {
+				continue
+			}
+
+			it.set[column] = true
+			value, err := strconv.Atoi(values[column])
+			if err != nil || value < 0 {
+				fail("%s must be a whole number of at least 0", column)
+			}
+
+			if column == "reorder_point" {
+				product.ReorderPoint = value
+			} else {
+				product.ReorderQuantity = value
+			}
+		}
+
+		if len(rowErrors) == failures {
+			items = append(items, it)
+		}
+	}
+
+	return items, rowErrors, nil
+}
+
+// apply writes one validated row in its own transaction, the way the create
+// and edit endpoints do: new stock and stock changes go through the ledger.
+func apply(db *gorm.DB, env *config.Env, userID *string, it item) (bool, error) {
+	created := it.existing == nil
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if created {
+			return createProduct(tx, env, userID, it.product)
+		}
+		return updateProduct(tx, env, userID, it)
+	})
+
+	return created, err
+}
+
+func createProduct(tx *gorm.DB, env *config.Env, userID *string, create entity.ProductCreate) error {
+	product := entity.NewProduct(create, env)
+	initialStock := product.StockQuantity
+	product.StockQuantity = 0
+
+	var err error
+	product.Slug, err = slug.Unique(tx, "products", entity.SlugEntityProduct, product.Name, "")
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Create(product).Error; err != nil {
+		return err
+	}
+
+	if initialStock == 0 {
+		return nil
+	}
+
+	movement := entity.NewInventoryMovement(product.ID, initialStock, entity.InventoryPurchase)
+	movement.UserID = userID
+	movement.Note = "Initial stock (import)"
+	return recordMovement(tx, env, movement)
+}
+
+func updateProduct(tx *gorm.DB, env *config.Env, userID *string, it item) error {
+	product := it.product
+	columns := map[string]interface{}{
+		"name":             product.Name,
+		"description":      product.Description,
+		"price":            product.Price,
+		"category_id":      product.CategoryID,
+		"weight":           product.Weight,
+		"dimensions":       product.Dimensions,
+		"is_featured":      product.IsFeatured,
+		"reorder_point":    product.ReorderPoint,
+		"reorder_quantity": product.ReorderQuantity,
+	}
+
+	updates := map[string]interface{}{}
+	for column, value := range columns {
+		if it.set[column] {
+			updates[column] = value
+		}
+	}
+
+	var current entity.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND deleted_at IS NULL", it.existing.ID).
+		First(&current).Error; err != nil {
+		return err
+	}
+
+	if len(updates) > 0 {
+		if err := tx.Model(&current).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+
+	if it.set["name"] {
+		if _, err := slug.Rename(tx, "products", entity.SlugEntityProduct, current.ID, current.Slug, product.Name); err != nil {
+			return err
+		}
+	}
+
+	if !it.set["stock_quantity"] {
+		return nil
+	}
+
+	delta := product.StockQuantity - current.StockQuantity
+	if delta == 0 {
+		return nil
+	}
+
+	movement := entity.NewInventoryMovement(current.ID, delta, entity.InventoryAdjustment)
+	movement.UserID = userID
+	movement.Note = "Stock set through import"
+	return recordMovement(tx, env, movement)
+}
+
+func recordMovement(tx *gorm.DB, env *config.Env, movement *entity.InventoryMovement) error {
+	err := inventory.RecordSpread(tx, movement, env.FulfillmentStrategy)
+	if errors.Is(err, inventory.ErrInsufficientStock) {
+		return errors.New("stock cannot go below what is already committed")
+	}
+	if errors.Is(err, inventory.ErrNoWarehouse) {
+		return errors.New("no active warehouse")
+	}
+	return err
+}
@@ -0,0 +1,164 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported file format, use .csv or .xlsx")
+
+// Row is one data line of the spreadsheet, keyed by normalized header.
+type Row struct {
+	Line   int
+	Values map[string]string
+}
+
+// Parse reads a CSV or XLSX file by its extension. The first line holds
+// the headers; for XLSX only the first sheet is read. CSV files may use
+// commas or semicolons, as spreadsheets saved with a Brazilian locale do.
+func Parse(filename string, data []byte) ([]Row, error) {
+	var records [][]string
+	var err error
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		records, err = readCSV(data)
+	case ".xlsx":
+		records, err = readXLSX(data)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, errors.New("file is empty")
+	}
+
+	headers := make([]string, len(records[0]))
+	for i, header := range records[0] {
+		headers[i] = normalizeHeader(header)
+	}
+
+	rows := make([]Row, 0, len(records)-1)
+	for i, record := range records[1:] {
+		row := Row{Line: i + 2, Values: map[string]string{}}
+
+		empty := true
+		for j, value := range record {
+			if j >= len(headers) || headers[j] == "" {
+				continue
+			}
+
+			value = strings.TrimSpace(value)
+			if value != "" {
+				empty = false
+			}
+			row.Values[headers[j]] = value
+		}
+
+		if !empty {
+			rows = append(rows, row)
+		}
+	}
+
+	return rows, nil
+}
+
+func readCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	return reader.ReadAll()
+}
+
+func readXLSX(data []byte) ([][]string, error) {
+	file, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sheets := file.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("workbook has no sheets")
+	}
+
+	// Raw values keep numbers as stored instead of formatted for the locale
+	// the workbook was saved in, e.g. "1234.5" rather than "R$ 1.234,50".
+	return file.GetRows(sheets[0], excelize.Options{RawCellValue: true})
+}
+
+func normalizeHeader(header string) string {
+	header = strings.ToLower(strings.TrimSpace(header))
+	header = strings.NewReplacer(" ", "_", "-", "_").Replace(header)
+	return header
+}
+
+var errInvalidDecimal = errors.New("invalid decimal number")
+
+// parseDecimal accepts "1234.5" as well as the Brazilian "1234,5" and
+// "1.234,50". A comma is always the decimal separator, so values such as
+// "1,234.50" are rejected instead of guessed, and so is "1.500", which is
+// 1500 in a Brazilian sheet and 1.5 elsewhere.
+func parseDecimal(value string) (float64, error) {
+	if _, decimals, _ := strings.Cut(value, "."); !strings.Contains(value, ",") && len(decimals) == 3 {
+		return 0, errInvalidDecimal
+	}
+
+	if integer, fraction, found := strings.Cut(value, ","); found {
+		if strings.ContainsAny(fraction, ".,") {
+			return 0, errInvalidDecimal
+		}
+
+		groups := strings.Split(integer, ".")
+		if lead := strings.TrimLeft(groups[0], "+-"); len(groups) > 1 && (lead == "" || len(lead) > 3) {
+			return 0, errInvalidDecimal
+		}
+
+		for _, group := range groups[1:] {
+			if len(group) != 3 {
+				return 0, errInvalidDecimal
+			}
+		}
+
+		value = strings.Join(groups, "") + "." + fraction
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	if math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, errInvalidDecimal
+	}
+
+	return number, nil
+}
+
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "sim", "s", "yes", "y":
+		return true, nil
+	case "não", "nao", "n", "no":
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
@@ -0,0 +1,195 @@
+package importer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []Row
+	}{
+		{
+			name: "commas",
+			data: "SKU,Name,Price\nA-1,Camiseta,49.9\n",
+			want: []Row{{Line: 2, Values: map[string]string{"sku": "A-1", "name": "Camiseta", "price": "49.9"}}},
+		},
+		{
+			name: "semicolons with decimal commas",
+			data: "sku;name;price\nA-1;Camiseta;1.234,50\n",
+			want: []Row{{Line: 2, Values: map[string]string{"sku": "A-1", "name": "Camiseta", "price": "1.234,50"}}},
+		},
+		{
+			name: "byte order mark and normalized headers",
+			data: "\xef\xbb\xbfSKU;Stock Quantity;is-featured\r\nA-1;3;sim\r\n",
+			want: []Row{{Line: 2, Values: map[string]string{"sku": "A-1", "stock_quantity": "3", "is_featured": "sim"}}},
+		},
+		{
+			name: "blank lines skipped, line numbers kept",
+			data: "sku,name\n , \nB-2, Bermuda \n",
+			want: []Row{{Line: 3, Values: map[string]string{"sku": "B-2", "name": "Bermuda"}}},
+		},
+		{
+			name: "extra columns ignored",
+			data: "sku,,name\nA-1,x,Camiseta,sobra\n",
+			want: []Row{{Line: 2, Values: map[string]string{"sku": "A-1", "name": "Camiseta"}}},
+		},
+		{
+			name: "headers only",
+			data: "sku,name\n",
+			want: []Row{},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse("produtos.CSV", []byte(tt.data))
+		if err != nil {
+			t.Errorf("%s: Parse error = %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Parse = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseXLSX(t *testing.T) {
+	file := excelize.NewFile()
+	defer file.Close()
+
+	sheet := file.GetSheetName(0)
+	file.SetSheetRow(sheet, "A1", &[]interface{}{"SKU", "Name", "Price", "Is Featured"})
+	file.SetSheetRow(sheet, "A2", &[]interface{}{"A-1", "Camiseta", 1234.5, true})
+
+	// A thousands format would turn the price into "1,234.50" if formatted
+	// values were read.
+	style, err := file.NewStyle(&excelize.Style{NumFmt: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.SetCellStyle(sheet, "C2", "C2", style)
+
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := Parse("produtos.xlsx", buffer.Bytes())
+	if err != nil {
+		t.Fatalf("Parse error = %v", err)
+	}
+
+	want := []Row{{Line: 2, Values: map[string]string{"sku": "A-1", "name": "Camiseta", "price": "1234.5", "is_featured": "1"}}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("Parse = %v, want %v", rows, want)
+	}
+
+	if price, err := parseDecimal(rows[0].Values["price"]); err != nil || price != 1234.5 {
+		t.Errorf("parseDecimal(%q) = %v, %v", rows[0].Values["price"], price, err)
+	}
+
+	if featured, err := parseBool(rows[0].Values["is_featured"]); err != nil || !featured {
+		t.Errorf("parseBool(%q) = %v, %v", rows[0].Values["is_featured"], featured, err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, err := Parse("produtos.xls", []byte("sku\nA-1\n")); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Parse(.xls) error = %v, want ErrUnsupportedFormat", err)
+	}
+
+	if _, err := Parse("produtos", []byte("sku\nA-1\n")); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Parse(no extension) error = %v, want ErrUnsupportedFormat", err)
+	}
+
+	if _, err := Parse("produtos.csv", nil); err == nil {
+		t.Error("Parse(empty csv) error = nil, want error")
+	}
+
+	if _, err := Parse("produtos.xlsx", []byte("not a workbook")); err == nil {
+		t.Error("Parse(invalid xlsx) error = nil, want error")
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+		ok    bool
+	}{
+		{"1234.5", 1234.5, true},
+		{"1234,5", 1234.5, true},
+		{"1.234,50", 1234.5, true},
+		{"1.234.567,89", 1234567.89, true},
+		{"-1.234,5", -1234.5, true},
+		{"0,99", 0.99, true},
+		{"42", 42, true},
+		{"1,234.50", 0, false},
+		{"1.234,5.0", 0, false},
+		{"1,234,567", 0, false},
+		{"12.34,5", 0, false},
+		{"1234.567,8", 0, false},
+		{".234,5", 0, false},
+		{"1.2.3", 0, false},
+		{"1.500", 0, false},
+		{"1.234.567", 0, false},
+		{"-2.500", 0, false},
+		{"1.5", 1.5, true},
+		{"1.50", 1.5, true},
+		{"1.5000", 1.5, true},
+		{"abc", 0, false},
+		{"", 0, false},
+		{"NaN", 0, false},
+		{"Inf", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, err := parseDecimal(tt.value)
+		if (err == nil) != tt.ok {
+			t.Errorf("parseDecimal(%q) error = %v, want ok %v", tt.value, err, tt.ok)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseDecimal(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseBool(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+		ok    bool
+	}{
+		{"sim", true, true},
+		{"SIM", true, true},
+		{"s", true, true},
+		{"yes", true, true},
+		{"true", true, true},
+		{"1", true, true},
+		{"não", false, true},
+		{"NAO", false, true},
+		{"n", false, true},
+		{"no", false, true},
+		{"false", false, true},
+		{"0", false, true},
+		{"talvez", false, false},
+		{"", false, false},
+	}
+
+	for _, tt := range tests {
+		got, err := parseBool(tt.value)
+		if (err == nil) != tt.ok {
+			t.Errorf("parseBool(%q) error = %v, want ok %v", tt.value, err, tt.ok)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseBool(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package inventory
+
+import (
+	"errors"
+
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrInvalidMovement   = errors.New("quantity sign does not match the movement reason")
+	ErrNoWarehouse       = errors.New("no active warehouse")
+)
+
+// Record applies a movement to the warehouse and product stock and appends
+// it to the ledger. It must run inside the caller's transaction so the
+// ledger, warehouse_stocks and products.stock_quantity never drift apart.
+// Movements without a warehouse go to the default one. Variant movements
+// apply to the variant row instead.
+func Record(tx *gorm.DB, movement *entity.InventoryMovement) error {
+	if !movement.Reason.ValidQuantity(movement.Quantity) {
+		return ErrInvalidMovement
+	}
+
+	if movement.VariantID != nil {
+		return recordVariant(tx, movement)
+	}
+
+	if movement.WarehouseID == nil {
+		warehouse, err := DefaultWarehouse(tx)
+		if err != nil {
+			return err
+		}
+		movement.WarehouseID = &warehouse.ID
+	}
+
+	result := tx.Model(&entity.Product{}).
+		Where("id = ? AND stock_quantity + ? >= 0", movement.ProductID, movement.Quantity).
+		Update("stock_quantity", gorm.Expr("stock_quantity + ?", movement.Quantity))
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := tx.Model(&entity.Product{}).Where("id = ?", movement.ProductID).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return ErrInsufficientStock
+	}
+
+	if err := applyWarehouseStock(tx, *movement.WarehouseID, movement.ProductID, movement.Quantity); err != nil {
+		return err
+	}
+
+	if err := tx.Model(&entity.Product{}).
+		Select("stock_quantity").
+		Where("id = ?", movement.ProductID).
+		Scan(&movement.BalanceAfter).Error; err != nil {
+		return err
+	}
+
+	return tx.Create(movement).Error
+}
+
+// RecordSpread is Record for a product movement that names no warehouse.
+// Stock may be spread across warehouses, so a reduction is taken from them
+// in the order strategy ships from, as one movement per warehouse.
+func RecordSpread(tx *gorm.DB, movement *entity.InventoryMovement, strategy string) error {
+	if movement.Quantity > 0 || movement.WarehouseID != nil || movement.VariantID != nil {
+		return Record(tx, movement)
+	}
+
+	allocations, err := Allocate(tx, movement.ProductID, -movement.Quantity, strategy, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, allocation := range allocations {
+		part := entity.NewInventoryMovement(movement.ProductID, -allocation.Quantity, movement.Reason)
+		part.WarehouseID = &allocation.WarehouseID
+		part.UserID = movement.UserID
+		part.OrderID = movement.OrderID
+		part.Note = movement.Note
+		if err := Record(tx, part); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordVariant applies a movement to the stock of a variant, which is not
+// split across warehouses. Deleted variants still take returns.
+func recordVariant(tx *gorm.DB, movement *entity.InventoryMovement) error {
+	movement.WarehouseID = nil
+
+	variants := tx.Unscoped().Model(&entity.ProductVariant{}).
+		Where("id = ? AND product_id = ?", *movement.VariantID, movement.ProductID)
+
+	result := variants.Session(&gorm.Session{}).
+		Where("stock_quantity + ? >= 0", movement.Quantity).
+		Update("stock_quantity", gorm.Expr("stock_quantity + ?", movement.Quantity))
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := variants.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return ErrInsufficientStock
+	}
+
+	if err := variants.Session(&gorm.Session{}).
+		Select("stock_quantity").
+		Scan(&movement.BalanceAfter).Error; err != nil {
+		return err
+	}
+
+	return tx.Create(movement).Error
+}
@@ -0,0 +1,163 @@
+package inventory
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gaspartv/api.ecommerce/src/external/notifier"
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"gorm.io/gorm"
+)
+
+// liveVariantsSQL selects the live variants of the products row of the
+// surrounding query.
+const liveVariantsSQL = "SELECT 1 FROM product_variants v WHERE v.product_id = products.id AND v.deleted_at IS NULL"
+
+// productLowStockCondition matches products without variants at or below
+// their reorder point. A reorder point of zero turns alerts off for the
+// product.
+const productLowStockCondition = "products.deleted_at IS NULL AND products.reorder_point > 0 AND products.stock_quantity <= products.reorder_point AND NOT EXISTS (" + liveVariantsSQL + ")"
+
+// variantLowStockCondition matches variants, joined with their product, at
+// or below the reorder point of the product.
+const variantLowStockCondition = "products.deleted_at IS NULL AND products.reorder_point > 0 AND product_variants.stock_quantity <= products.reorder_point"
+
+// LowStockCondition matches products that are low on stock themselves or,
+// for products sold through variants, have a variant that is.
+const LowStockCondition = "((" + productLowStockCondition + ") OR (products.deleted_at IS NULL AND products.reorder_point > 0 AND EXISTS (" +
+	liveVariantsSQL + " AND v.stock_quantity <= products.reorder_point)))"
+
+// ScanLowStock alerts once for every active product or variant that reached
+// its reorder point. It is alerted again only after its stock climbs back
+// above the threshold and drops again.
+func ScanLowStock(ctx context.Context, db *gorm.DB, notify notifier.Notifier) (int, error) {
+	if err := db.Model(&entity.Product{}).
+		Where("low_stock_alerted_at IS NOT NULL AND (reorder_point = 0 OR stock_quantity > reorder_point)").
+		Update("low_stock_alerted_at", nil).Error; err != nil {
+		return 0, err
+	}
+
+	if err := db.Model(&entity.ProductVariant{}).
+		Where("low_stock_alerted_at IS NOT NULL AND NOT EXISTS ("+
+			"SELECT 1 FROM products WHERE products.id = product_variants.product_id AND "+variantLowStockCondition+")").
+		Update("low_stock_alerted_at", nil).Error; err != nil {
+		return 0, err
+	}
+
+	var products []entity.Product
+	if err := db.Where(productLowStockCondition + " AND products.disabled_at IS NULL AND products.low_stock_alerted_at IS NULL").
+		Order("stock_quantity").
+		Find(&products).Error; err != nil {
+		return 0, err
+	}
+
+	var variants []entity.ProductVariant
+	if err := db.Preload("OptionValues.OptionType").
+		Joins("JOIN products ON products.id = product_variants.product_id").
+		Where(variantLowStockCondition + " AND products.disabled_at IS NULL AND product_variants.disabled_at IS NULL AND product_variants.low_stock_alerted_at IS NULL").
+		Order("product_variants.stock_quantity").
+		Find(&variants).Error; err != nil {
+		return 0, err
+	}
+
+	if len(products) == 0 && len(variants) == 0 {
+		return 0, nil
+	}
+
+	alerts := make([]notifier.LowStockAlert, 0, len(products)+len(variants))
+	productIDs := make([]string, 0, len(products))
+	for _, product := range products {
+		alerts = append(alerts, notifier.LowStockAlert{
+			ProductID:       product.ID,
+			Name:            product.Name,
+			Sku:             product.Sku,
+			StockQuantity:   product.StockQuantity,
+			ReorderPoint:    product.ReorderPoint,
+			ReorderQuantity: product.ReorderQuantity,
+		})
+		productIDs = append(productIDs, product.ID)
+	}
+
+	variantIDs := make([]string, 0, len(variants))
+	if len(variants) > 0 {
+		parentIDs := make([]string, 0, len(variants))
+		for _, variant := range variants {
+			parentIDs = append(parentIDs, variant.ProductID)
+		}
+
+		var parents []entity.Product
+		if err := db.Where("id IN ?", parentIDs).Find(&parents).Error; err != nil {
+			return 0, err
+		}
+
+		parentByID := make(map[string]entity.Product, len(parents))
+		for _, parent := range parents {
+			parentByID[parent.ID] = parent
+		}
+
+		for _, variant := range variants {
+			parent := parentByID[variant.ProductID]
+			alerts = append(alerts, notifier.LowStockAlert{
+				ProductID:       parent.ID,
+				VariantID:       variant.ID,
+				Name:            parent.Name + " - " + variant.Label(),
+				Sku:             variant.Sku,
+				StockQuantity:   variant.StockQuantity,
+				ReorderPoint:    parent.ReorderPoint,
+				ReorderQuantity: parent.ReorderQuantity,
+			})
+			variantIDs = append(variantIDs, variant.ID)
+		}
+	}
+
+	// Rows are only marked after a successful delivery so a failed
+	// notification is retried on the next scan.
+	if err := notify.NotifyLowStock(ctx, alerts); err != nil {
+		return 0, err
+	}
+
+	if len(productIDs) > 0 {
+		if err := db.Model(&entity.Product{}).
+			Where("id IN ?", productIDs).
+			Update("low_stock_alerted_at", gorm.Expr("NOW()")).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	if len(variantIDs) > 0 {
+		if err := db.Model(&entity.ProductVariant{}).
+			Where("id IN ?", variantIDs).
+			Update("low_stock_alerted_at", gorm.Expr("NOW()")).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	return len(alerts), nil
+}
+
+// StartLowStockMonitor runs ScanLowStock every interval until ctx is
+// cancelled. interval must be positive.
+func StartLowStockMonitor(ctx context.Context, db *gorm.DB, notify notifier.Notifier, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				alerted, err := ScanLowStock(ctx, db, notify)
+				if err != nil {
+					log.Println("Erro ao verificar estoque baixo:", err)
+					continue
+				}
+				if alerted > 0 {
+					log.Printf("%d produtos com estoque baixo notificados", alerted)
+				}
+			}
+		}
+	}()
+}
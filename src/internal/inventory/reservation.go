@@ -0,0 +1,144 @@
+package inventory
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"gorm.io/gorm"
+)
+
+const activeReservation = "released_at IS NULL AND consumed_at IS NULL AND expires_at > NOW()"
+
+// AvailableQuantitySQL selects stock minus active holds for the products row
+// of the surrounding query. Variant holds count against the variant only.
+const AvailableQuantitySQL = "products.stock_quantity - COALESCE((" +
+	"SELECT SUM(r.quantity) FROM stock_reservations r " +
+	"WHERE r.product_id = products.id AND r.variant_id IS NULL AND r.released_at IS NULL AND r.consumed_at IS NULL AND r.expires_at > NOW()" +
+	"), 0)"
+
+// Available returns the stock of a product that is not held by an active
+// reservation. Holds taken by excludeCartID are counted as available so a
+// cart never competes with its own reservation.
+func Available(tx *gorm.DB, productID string, excludeCartID string) (int, error) {
+	var stock int
+	if err := tx.Model(&entity.Product{}).
+		Select("stock_quantity").
+		Where("id = ?", productID).
+		Scan(&stock).Error; err != nil {
+		return 0, err
+	}
+
+	var reserved int
+	if err := tx.Model(&entity.StockReservation{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("product_id = ? AND variant_id IS NULL AND cart_id <> ? AND "+activeReservation, productID, excludeCartID).
+		Scan(&reserved).Error; err != nil {
+		return 0, err
+	}
+
+	return stock - reserved, nil
+}
+
+// AvailableVariant is Available for the stock of a variant.
+func AvailableVariant(tx *gorm.DB, variantID string, excludeCartID string) (int, error) {
+	var stock int
+	if err := tx.Model(&entity.ProductVariant{}).
+		Select("stock_quantity").
+		Where("id = ?", variantID).
+		Scan(&stock).Error; err != nil {
+		return 0, err
+	}
+
+	var reserved int
+	if err := tx.Model(&entity.StockReservation{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("variant_id = ? AND cart_id <> ? AND "+activeReservation, variantID, excludeCartID).
+		Scan(&reserved).Error; err != nil {
+		return 0, err
+	}
+
+	return stock - reserved, nil
+}
+
+// Reserve replaces the active holds of a cart with one hold per item. The
+// product and variant rows must already be locked by the caller.
+func Reserve(tx *gorm.DB, cartID string, items []entity.CartItem, ttl time.Duration) ([]entity.StockReservation, error) {
+	if err := ReleaseCart(tx, cartID); err != nil {
+		return nil, err
+	}
+
+	reservations := make([]entity.StockReservation, 0, len(items))
+	for _, item := range items {
+		var available int
+		var err error
+		if item.VariantID != nil {
+			available, err = AvailableVariant(tx, *item.VariantID, cartID)
+		} else {
+			available, err = Available(tx, item.ProductID, cartID)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if available < item.Quantity {
+			return nil, ErrInsufficientStock
+		}
+
+		reservations = append(reservations, *entity.NewStockReservation(cartID, item.ProductID, item.VariantID, item.Quantity, ttl))
+	}
+
+	if len(reservations) == 0 {
+		return reservations, nil
+	}
+
+	return reservations, tx.Create(&reservations).Error
+}
+
+func ReleaseCart(tx *gorm.DB, cartID string) error {
+	return tx.Model(&entity.StockReservation{}).
+		Where("cart_id = ? AND "+activeReservation, cartID).
+		Update("released_at", gorm.Expr("NOW()")).Error
+}
+
+func ConsumeCart(tx *gorm.DB, cartID string) error {
+	return tx.Model(&entity.StockReservation{}).
+		Where("cart_id = ? AND "+activeReservation, cartID).
+		Update("consumed_at", gorm.Expr("NOW()")).Error
+}
+
+func ReleaseExpired(db *gorm.DB) (int64, error) {
+	result := db.Model(&entity.StockReservation{}).
+		Where("released_at IS NULL AND consumed_at IS NULL AND expires_at <= NOW()").
+		Update("released_at", gorm.Expr("NOW()"))
+
+	return result.RowsAffected, result.Error
+}
+
+// StartReservationSweeper releases expired holds every interval until ctx is
+// cancelled. Expired holds already stop counting on their own; the sweeper
+// keeps the table honest for reporting. interval must be positive.
+func StartReservationSweeper(ctx context.Context, db *gorm.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				released, err := ReleaseExpired(db)
+				if err != nil {
+					log.Println("Erro ao liberar reservas expiradas:", err)
+					continue
+				}
+				if released > 0 {
+					log.Printf("%d reservas de estoque expiradas liberadas", released)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,174 @@
+package inventory
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Fulfillment strategies decide which warehouses ship an order line.
+const (
+	StrategyPriority  = "priority"
+	StrategyMostStock = "most_stock"
+	StrategyClosest   = "closest"
+)
+
+// DefaultWarehouseCode is the code of the warehouse seeded on startup.
+// Movements that do not name a warehouse go to DefaultWarehouse, which is
+// picked by priority and is not necessarily this one.
+const DefaultWarehouseCode = "MAIN"
+
+const activeWarehouse = "warehouses.deleted_at IS NULL AND warehouses.disabled_at IS NULL"
+
+// Point is a shipping destination used by the closest strategy.
+type Point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Allocation is the quantity of an order line taken from one warehouse.
+type Allocation struct {
+	WarehouseID string
+	Quantity    int
+}
+
+type warehouseLevel struct {
+	WarehouseID string
+	Quantity    int
+	Priority    int
+	Latitude    *float64
+	Longitude   *float64
+}
+
+// DefaultWarehouse returns the active warehouse with the lowest priority
+// value, falling back to the oldest one on ties.
+func DefaultWarehouse(tx *gorm.DB) (*entity.Warehouse, error) {
+	var warehouse entity.Warehouse
+	err := tx.Where("deleted_at IS NULL AND disabled_at IS NULL").
+		Order("priority, created_at").
+		First(&warehouse).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoWarehouse
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &warehouse, nil
+}
+
+// Allocate picks the warehouses that ship quantity units of a product,
+// ranked by strategy, splitting the line when no single warehouse has
+// enough. The stock rows are locked until the transaction ends. origin is
+// only used by the closest strategy, which falls back to priority without it.
+func Allocate(tx *gorm.DB, productID string, quantity int, strategy string, origin *Point) ([]Allocation, error) {
+	var levels []warehouseLevel
+	if err := tx.Table("warehouse_stocks").
+		Select("warehouse_stocks.warehouse_id, warehouse_stocks.quantity, warehouses.priority, warehouses.latitude, warehouses.longitude").
+		Joins("JOIN warehouses ON warehouses.id = warehouse_stocks.warehouse_id").
+		Where("warehouse_stocks.product_id = ? AND warehouse_stocks.quantity > 0 AND "+activeWarehouse, productID).
+		Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "warehouse_stocks"}}).
+		Scan(&levels).Error; err != nil {
+		return nil, err
+	}
+
+	rankWarehouses(levels, strategy, origin)
+
+	var allocations []Allocation
+	remaining := quantity
+	for _, level := range levels {
+		if remaining == 0 {
+			break
+		}
+
+		take := min(level.Quantity, remaining)
+		allocations = append(allocations, Allocation{WarehouseID: level.WarehouseID, Quantity: take})
+		remaining -= take
+	}
+
+	if remaining > 0 {
+		return nil, ErrInsufficientStock
+	}
+
+	return allocations, nil
+}
+
+func rankWarehouses(levels []warehouseLevel, strategy string, origin *Point) {
+	byPriority := func(a, b warehouseLevel) bool {
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		return a.WarehouseID < b.WarehouseID
+	}
+
+	switch {
+	case strategy == StrategyMostStock:
+		sort.SliceStable(levels, func(i, j int) bool {
+			if levels[i].Quantity != levels[j].Quantity {
+				return levels[i].Quantity > levels[j].Quantity
+			}
+			return byPriority(levels[i], levels[j])
+		})
+	case strategy == StrategyClosest && origin != nil:
+		// Warehouses without coordinates go last.
+		distance := func(level warehouseLevel) float64 {
+			if level.Latitude == nil || level.Longitude == nil {
+				return math.Inf(1)
+			}
+			return haversine(*origin, Point{*level.Latitude, *level.Longitude})
+		}
+		sort.SliceStable(levels, func(i, j int) bool {
+			di, dj := distance(levels[i]), distance(levels[j])
+			if di != dj {
+				return di < dj
+			}
+			return byPriority(levels[i], levels[j])
+		})
+	default:
+		sort.SliceStable(levels, func(i, j int) bool { return byPriority(levels[i], levels[j]) })
+	}
+}
+
+// haversine returns the great-circle distance between two points in km.
+func haversine(a, b Point) float64 {
+	const earthRadius = 6371.0
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(b.Latitude - a.Latitude)
+	dLon := toRad(b.Longitude - a.Longitude)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(a.Latitude))*math.Cos(toRad(b.Latitude))*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+func applyWarehouseStock(tx *gorm.DB, warehouseID string, productID string, quantity int) error {
+	if quantity > 0 {
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "warehouse_id"}, {Name: "product_id"}},
+			DoUpdates: clause.Set{{Column: clause.Column{Name: "quantity"}, Value: gorm.Expr("warehouse_stocks.quantity + ?", quantity)}},
+		}).Create(&entity.WarehouseStock{
+			WarehouseID: warehouseID,
+			ProductID:   productID,
+			Quantity:    quantity,
+		}).Error
+	}
+
+	result := tx.Model(&entity.WarehouseStock{}).
+		Where("warehouse_id = ? AND product_id = ? AND quantity + ? >= 0", warehouseID, productID, quantity).
+		Update("quantity", gorm.Expr("quantity + ?", quantity))
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrInsufficientStock
+	}
+
+	return nil
+}
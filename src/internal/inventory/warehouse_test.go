@@ -0,0 +1,72 @@
+package inventory
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestHaversine(t *testing.T) {
+	saoPaulo := Point{-23.5505, -46.6333}
+	rio := Point{-22.9068, -43.1729}
+
+	tests := []struct {
+		name string
+		a, b Point
+		want float64
+	}{
+		{"same point", saoPaulo, saoPaulo, 0},
+		{"sao paulo to rio", saoPaulo, rio, 361},
+		{"quarter meridian", Point{0, 0}, Point{90, 0}, 10008},
+	}
+
+	for _, tt := range tests {
+		if got := haversine(tt.a, tt.b); math.Abs(got-tt.want) > 1 {
+			t.Errorf("%s: haversine = %.1f km, want %.0f km", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRankWarehouses(t *testing.T) {
+	ptr := func(v float64) *float64 { return &v }
+
+	levels := func() []warehouseLevel {
+		return []warehouseLevel{
+			{WarehouseID: "far", Quantity: 50, Priority: 1, Latitude: ptr(-3.7), Longitude: ptr(-38.5)},
+			{WarehouseID: "near", Quantity: 5, Priority: 2, Latitude: ptr(-23.5), Longitude: ptr(-46.6)},
+			{WarehouseID: "nowhere", Quantity: 50, Priority: 0},
+			{WarehouseID: "alpha", Quantity: 5, Priority: 2},
+		}
+	}
+
+	origin := &Point{-22.9, -43.2}
+
+	tests := []struct {
+		name     string
+		strategy string
+		origin   *Point
+		want     []string
+	}{
+		{"priority", StrategyPriority, nil, []string{"nowhere", "far", "alpha", "near"}},
+		{"most stock", StrategyMostStock, nil, []string{"nowhere", "far", "alpha", "near"}},
+		{"closest", StrategyClosest, origin, []string{"near", "far", "nowhere", "alpha"}},
+		{"closest without origin", StrategyClosest, nil, []string{"nowhere", "far", "alpha", "near"}},
+		{"unknown strategy", "random", origin, []string{"nowhere", "far", "alpha", "near"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranked := levels()
+			rankWarehouses(ranked, tt.strategy, tt.origin)
+
+			got := make([]string, 0, len(ranked))
+			for _, level := range ranked {
+				got = append(got, level.WarehouseID)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	"github.com/disintegration/imaging"
+	"github.com/gen2brain/webp"
+)
+
+var (
+	ErrImageTooLarge    = errors.New("image exceeds the size limit")
+	ErrUnsupportedImage = errors.New("file is not a supported image")
+)
+
+// maxPixels bounds the decoded size, so a small file declaring huge
+// dimensions cannot exhaust memory.
+const maxPixels = 50_000_000
+
+const (
+	jpegQuality = 85
+	webpQuality = 80
+)
+
+var allowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+type renditionSize struct {
+	name string
+	size int
+	crop bool
+}
+
+// Thumbnails are cropped to a square; the other sizes keep the aspect ratio
+// and are never upscaled.
+var renditionSizes = []renditionSize{
+	{"thumb", 150, true},
+	{"medium", 600, false},
+	{"large", 1200, false},
+}
+
+// Rendition is one encoded size of an uploaded image. Name is the size
+// name, with a "_webp" suffix for the WebP copy.
+type Rendition struct {
+	Name        string
+	Ext         string
+	ContentType string
+	Data        []byte
+}
+
+// Read reads an upload, failing with ErrImageTooLarge past maxBytes.
+func Read(r io.Reader, maxBytes int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > maxBytes {
+		return nil, ErrImageTooLarge
+	}
+
+	return data, nil
+}
+
+// Process checks that data is a real image by its content, not by what the
+// client claims, and returns its renditions. Decoding applies the EXIF
+// orientation, and since only pixels are re-encoded no metadata survives.
+// Opaque images are stored as JPEG and images with transparency as PNG,
+// each alongside a WebP copy.
+func Process(data []byte) ([]Rendition, error) {
+	img, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	renditions := make([]Rendition, 0, len(renditionSizes)*2)
+	for _, size := range renditionSizes {
+		resized := resize(img, size)
+
+		rendition, err := encode(size.name, resized)
+		if err != nil {
+			return nil, err
+		}
+
+		webpRendition, err := encodeWebP(size.name+"_webp", resized)
+		if err != nil {
+			return nil, err
+		}
+
+		renditions = append(renditions, *rendition, *webpRendition)
+	}
+
+	return renditions, nil
+}
+
+// ProcessSize is Process for targets that keep a single image: only the
+// named size is encoded, without its WebP copy.
+func ProcessSize(data []byte, name string) (*Rendition, error) {
+	img, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, size := range renditionSizes {
+		if size.name == name {
+			return encode(size.name, resize(img, size))
+		}
+	}
+
+	return nil, fmt.Errorf("unknown rendition %q", name)
+}
+
+func decode(data []byte) (image.Image, error) {
+	if !allowedTypes[http.DetectContentType(data)] {
+		return nil, ErrUnsupportedImage
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+
+	if config.Width*config.Height > maxPixels {
+		return nil, ErrImageTooLarge
+	}
+
+	img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+
+	return img, nil
+}
+
+func resize(img image.Image, size renditionSize) *image.NRGBA {
+	if size.crop {
+		return imaging.Fill(img, size.size, size.size, imaging.Center, imaging.Lanczos)
+	}
+
+	bounds := img.Bounds()
+	if bounds.Dx() <= size.size && bounds.Dy() <= size.size {
+		return imaging.Clone(img)
+	}
+
+	return imaging.Fit(img, size.size, size.size, imaging.Lanczos)
+}
+
+func encode(name string, img *image.NRGBA) (*Rendition, error) {
+	var buf bytes.Buffer
+
+	if img.Opaque() {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, err
+		}
+		return &Rendition{Name: name, Ext: ".jpg", ContentType: "image/jpeg", Data: buf.Bytes()}, nil
+	}
+
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return &Rendition{Name: name, Ext: ".png", ContentType: "image/png", Data: buf.Bytes()}, nil
+}
+
+// encodeWebP writes a lossy WebP, which keeps the alpha channel and comes
+// out smaller than the JPEG or PNG of the same size.
+func encodeWebP(name string, img *image.NRGBA) (*Rendition, error) {
+	var buf bytes.Buffer
+
+	if err := webp.Encode(&buf, img, webp.Options{Quality: webpQuality}); err != nil {
+		return nil, err
+	}
+	return &Rendition{Name: name, Ext: ".webp", ContentType: "image/webp", Data: buf.Bytes()}, nil
+}
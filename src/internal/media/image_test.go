@@ -0,0 +1,156 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, width int, height int, alpha uint8) []byte {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{uint8(x), uint8(y), 128, alpha})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// pngHeader is a PNG that stops after a valid IHDR chunk declaring the
+// given size, enough for DecodeConfig.
+func pngHeader(width uint32, height uint32) []byte {
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], width)
+	binary.BigEndian.PutUint32(ihdr[4:], height)
+	ihdr[8] = 8 // bit depth
+	ihdr[9] = 6 // RGBA
+
+	var buf bytes.Buffer
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(&buf, binary.BigEndian, uint32(len(ihdr)))
+	chunk := append([]byte("IHDR"), ihdr...)
+	buf.Write(chunk)
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(chunk))
+	return buf.Bytes()
+}
+
+func TestProcessRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"text", []byte("hello, world"), ErrUnsupportedImage},
+		{"html", []byte("<html><body>not an image</body></html>"), ErrUnsupportedImage},
+		{"truncated gif", []byte("GIF89a\x01\x00"), ErrUnsupportedImage},
+		{"too many pixels", pngHeader(10000, 10000), ErrImageTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Process(tt.data); !errors.Is(err, tt.want) {
+				t.Fatalf("Process error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessRenditions(t *testing.T) {
+	tests := []struct {
+		name        string
+		width       int
+		height      int
+		alpha       uint8
+		contentType string
+		sizes       map[string]image.Point
+	}{
+		{
+			name: "opaque landscape", width: 2000, height: 1000, alpha: 255, contentType: "image/jpeg",
+			sizes: map[string]image.Point{"thumb": {150, 150}, "medium": {600, 300}, "large": {1200, 600}},
+		},
+		{
+			name: "small with alpha is not upscaled", width: 100, height: 50, alpha: 128, contentType: "image/png",
+			sizes: map[string]image.Point{"thumb": {150, 150}, "medium": {100, 50}, "large": {100, 50}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renditions, err := Process(encodePNG(t, tt.width, tt.height, tt.alpha))
+			if err != nil {
+				t.Fatalf("Process: %v", err)
+			}
+
+			if len(renditions) != 6 {
+				t.Fatalf("got %d renditions, want 6", len(renditions))
+			}
+
+			byName := map[string]Rendition{}
+			for _, rendition := range renditions {
+				byName[rendition.Name] = rendition
+			}
+
+			for name, size := range tt.sizes {
+				for _, rendition := range []Rendition{byName[name], byName[name+"_webp"]} {
+					wantType := tt.contentType
+					if rendition.Name == name+"_webp" {
+						wantType = "image/webp"
+					}
+
+					if rendition.ContentType != wantType {
+						t.Errorf("%s content type = %q, want %q", rendition.Name, rendition.ContentType, wantType)
+					}
+
+					config, _, err := image.DecodeConfig(bytes.NewReader(rendition.Data))
+					if err != nil {
+						t.Fatalf("%s: decode: %v", rendition.Name, err)
+					}
+
+					if got := (image.Point{config.Width, config.Height}); got != size {
+						t.Errorf("%s size = %v, want %v", rendition.Name, got, size)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestProcessSize(t *testing.T) {
+	rendition, err := ProcessSize(encodePNG(t, 2000, 1000, 255), "large")
+	if err != nil {
+		t.Fatalf("ProcessSize: %v", err)
+	}
+
+	if rendition.Name != "large" || rendition.ContentType != "image/jpeg" {
+		t.Errorf("rendition = %s %s, want large image/jpeg", rendition.Name, rendition.ContentType)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(rendition.Data))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	if got := (image.Point{config.Width, config.Height}); got != (image.Point{1200, 600}) {
+		t.Errorf("size = %v, want (1200,600)", got)
+	}
+
+	if _, err := ProcessSize(encodePNG(t, 10, 10, 255), "huge"); err == nil {
+		t.Error("ProcessSize(unknown size) error = nil, want error")
+	}
+
+	if _, err := ProcessSize([]byte("hello, world"), "large"); !errors.Is(err, ErrUnsupportedImage) {
+		t.Errorf("ProcessSize(text) error = %v, want ErrUnsupportedImage", err)
+	}
+}
@@ -0,0 +1,146 @@
+package media
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gaspartv/api.ecommerce/src/external/storage"
+	"gorm.io/gorm"
+)
+
+// referencedURLsSQL lists every image URL still in use. Rows of deleted
+// categories, products, variants and users no longer hold on to their
+// images, and neither does the gallery of a deleted product.
+const referencedURLsSQL = `
+SELECT image AS url FROM categories WHERE deleted_at IS NULL
+UNION SELECT v.value FROM categories, jsonb_each_text(categories.image_variants) v WHERE deleted_at IS NULL
+UNION SELECT image FROM products WHERE deleted_at IS NULL
+UNION SELECT v.value FROM products, jsonb_each_text(products.image_variants) v WHERE deleted_at IS NULL
+UNION SELECT pi.url FROM product_images pi JOIN products p ON p.id = pi.product_id WHERE p.deleted_at IS NULL
+UNION SELECT v.value FROM product_images pi JOIN products p ON p.id = pi.product_id, jsonb_each_text(pi.variants) v WHERE p.deleted_at IS NULL
+UNION SELECT image FROM product_variants WHERE deleted_at IS NULL AND image IS NOT NULL
+UNION SELECT image FROM users WHERE deleted_at IS NULL AND image IS NOT NULL`
+
+// IsReferenced reports whether any live row still points at url.
+func IsReferenced(db *gorm.DB, url string) (bool, error) {
+	var referenced bool
+	err := db.Raw("SELECT EXISTS (SELECT 1 FROM ("+referencedURLsSQL+") refs WHERE url = ?)", url).
+		Scan(&referenced).Error
+	return referenced, err
+}
+
+// ManagedPrefixes are the key prefixes the API writes to. Reconcile never
+// looks outside them, so other files kept in the bucket are left alone.
+var ManagedPrefixes = []string{"categories/", "products/", "variants/", "users/", "uploads/"}
+
+type ReconcileOptions struct {
+	// DryRun only reports the orphaned objects.
+	DryRun bool
+	// MinAge protects objects younger than this, which may belong to an
+	// upload whose row is not written yet.
+	MinAge time.Duration
+	// DefaultImage is the placeholder URL, kept even when no row uses it.
+	DefaultImage string
+}
+
+type ReconcileReport struct {
+	Scanned  int      `json:"scanned"`
+	Recent   int      `json:"recent"`
+	Orphaned []string `json:"orphaned"`
+	Deleted  int      `json:"deleted"`
+	Failed   int      `json:"failed"`
+}
+
+// Reconcile lists the managed prefixes of the store and deletes (or, in
+// dry-run, reports) the objects no row refers to. Pending presigned uploads
+// count as referenced until they expire.
+func Reconcile(ctx context.Context, db *gorm.DB, store storage.ObjectStore, options ReconcileOptions) (*ReconcileReport, error) {
+	var urls []string
+	if err := db.Raw(referencedURLsSQL).Scan(&urls).Error; err != nil {
+		return nil, err
+	}
+
+	referenced := make(map[string]bool, len(urls))
+	for _, url := range append(urls, options.DefaultImage) {
+		if key, ok := storage.KeyFromURL(store, url); ok {
+			referenced[key] = true
+		}
+	}
+
+	var pending []string
+	if err := db.Table("uploads").
+		Where("completed_at IS NULL AND expires_at > NOW()").
+		Pluck("key", &pending).Error; err != nil {
+		return nil, err
+	}
+
+	for _, key := range pending {
+		referenced[key] = true
+	}
+
+	report := &ReconcileReport{Orphaned: []string{}}
+	cutoff := time.Now().Add(-options.MinAge)
+
+	visit := func(info storage.ObjectInfo) error {
+		report.Scanned++
+
+		if referenced[info.Key] {
+			return nil
+		}
+
+		if info.LastModified.After(cutoff) {
+			report.Recent++
+			return nil
+		}
+
+		report.Orphaned = append(report.Orphaned, info.Key)
+		if options.DryRun {
+			return nil
+		}
+
+		if err := store.Delete(ctx, info.Key); err != nil {
+			log.Printf("Erro ao remover objeto órfão %s: %v", info.Key, err)
+			report.Failed++
+			return nil
+		}
+
+		report.Deleted++
+		return nil
+	}
+
+	for _, prefix := range ManagedPrefixes {
+		if err := store.List(ctx, prefix, visit); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// StartReconciler runs Reconcile on an interval until ctx is cancelled.
+func StartReconciler(ctx context.Context, db *gorm.DB, store storage.ObjectStore, interval time.Duration, options ReconcileOptions) {
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				report, err := Reconcile(ctx, db, store, options)
+				if err != nil {
+					log.Println("Erro ao reconciliar arquivos:", err)
+					continue
+				}
+
+				if len(report.Orphaned) > 0 {
+					log.Printf("Reconciliação de arquivos: %d órfãos encontrados, %d removidos, %d falhas (dry-run: %t)",
+						len(report.Orphaned), report.Deleted, report.Failed, options.DryRun)
+				}
+			}
+		}
+	}()
+}
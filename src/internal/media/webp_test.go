@@ -0,0 +1,97 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math/rand"
+	"testing"
+
+	"github.com/gen2brain/webp"
+)
+
+// photo is a smooth gradient with some grain, closer to a photo than to a
+// flat graphic.
+func photo(width int, height int, alpha func(x, y int) uint8) *image.NRGBA {
+	random := rand.New(rand.NewSource(1))
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			grain := random.Intn(16)
+			img.SetNRGBA(x, y, color.NRGBA{
+				uint8(x*255/width + grain/2),
+				uint8(y*255/height + grain/2),
+				uint8((x+y)*128/(width+height) + grain),
+				alpha(x, y),
+			})
+		}
+	}
+	return img
+}
+
+func TestEncodeWebP(t *testing.T) {
+	tests := []struct {
+		name   string
+		width  int
+		height int
+		alpha  func(x, y int) uint8
+	}{
+		{"opaque", 600, 400, func(x, y int) uint8 { return 255 }},
+		{"alpha", 300, 200, func(x, y int) uint8 { return uint8(x * 255 / 300) }},
+		{"1x1", 1, 1, func(x, y int) uint8 { return 78 }},
+		{"odd size", 257, 3, func(x, y int) uint8 { return 255 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := photo(tt.width, tt.height, tt.alpha)
+
+			rendition, err := encodeWebP("large_webp", src)
+			if err != nil {
+				t.Fatalf("encodeWebP: %v", err)
+			}
+
+			decoded, err := webp.Decode(bytes.NewReader(rendition.Data))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+
+			if got := decoded.Bounds().Size(); got != src.Bounds().Size() {
+				t.Fatalf("size = %v, want %v", got, src.Bounds().Size())
+			}
+
+			if src.Opaque() {
+				return
+			}
+
+			// Lossy compression keeps the alpha channel close to the source.
+			for _, p := range []image.Point{{0, 0}, {tt.width - 1, tt.height - 1}, {tt.width / 2, tt.height / 2}} {
+				_, _, _, a := decoded.At(p.X, p.Y).RGBA()
+				want := int(src.NRGBAAt(p.X, p.Y).A)
+				if got := int(a >> 8); got < want-8 || got > want+8 {
+					t.Errorf("alpha at %v = %d, want about %d", p, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeWebPSmallerThanJPEG(t *testing.T) {
+	src := photo(1200, 800, func(x, y int) uint8 { return 255 })
+
+	rendition, err := encodeWebP("large_webp", src)
+	if err != nil {
+		t.Fatalf("encodeWebP: %v", err)
+	}
+
+	var jpg bytes.Buffer
+	if err := jpeg.Encode(&jpg, src, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		t.Fatalf("jpeg.Encode: %v", err)
+	}
+
+	if len(rendition.Data) >= jpg.Len() {
+		t.Errorf("WebP is %d bytes, JPEG is %d bytes", len(rendition.Data), jpg.Len())
+	}
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gaspartv/api.ecommerce/src/config"
+	"github.com/gaspartv/api.ecommerce/src/internal/auth"
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const userContextKey = "user"
+
+type authError struct {
+	status  int
+	message string
+}
+
+func Auth(db *gorm.DB, env *config.Env) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, authErr := authenticate(ctx, db, env)
+		if authErr != nil {
+			ctx.AbortWithStatusJSON(authErr.status, gin.H{"error": authErr.message})
+			return
+		}
+
+		ctx.Set(userContextKey, user)
+		ctx.Next()
+	}
+}
+
+// OptionalAuth lets anonymous requests through but still rejects a bearer
+// token that is present and invalid, so clients know to refresh it.
+func OptionalAuth(db *gorm.DB, env *config.Env) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.GetHeader("Authorization") == "" {
+			ctx.Next()
+			return
+		}
+
+		user, authErr := authenticate(ctx, db, env)
+		if authErr != nil {
+			ctx.AbortWithStatusJSON(authErr.status, gin.H{"error": authErr.message})
+			return
+		}
+
+		ctx.Set(userContextKey, user)
+		ctx.Next()
+	}
+}
+
+// CurrentUser returns the user attached by Auth, or nil on anonymous routes.
+func CurrentUser(ctx *gin.Context) *entity.User {
+	value, exists := ctx.Get(userContextKey)
+	if !exists {
+		return nil
+	}
+
+	user, _ := value.(*entity.User)
+	return user
+}
+
+func authenticate(ctx *gin.Context, db *gorm.DB, env *config.Env) (*entity.User, *authError) {
+	header := ctx.GetHeader("Authorization")
+	token, found := strings.CutPrefix(header, "Bearer ")
+	if !found || token == "" {
+		return nil, &authError{http.StatusUnauthorized, "Authorization token is required"}
+	}
+
+	claims, err := auth.ParseAccessToken(token, env)
+	if err != nil {
+		return nil, &authError{http.StatusUnauthorized, "Invalid or expired token"}
+	}
+
+	var session entity.Session
+	if err := db.Where("id = ? AND user_id = ? AND revoked_at IS NULL", claims.SessionID, claims.Subject).
+		First(&session).Error; err != nil {
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &authError{http.StatusUnauthorized, "Session has been revoked"}
+		}
+		return nil, &authError{http.StatusInternalServerError, err.Error()}
+	}
+
+	var user entity.User
+	if err := db.Preload("Role.Permissions").
+		Where("id = ? AND deleted_at IS NULL", claims.Subject).
+		First(&user).Error; err != nil {
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &authError{http.StatusUnauthorized, "User not found"}
+		}
+		return nil, &authError{http.StatusInternalServerError, err.Error()}
+	}
+
+	if user.DisabledAt != nil {
+		return nil, &authError{http.StatusForbidden, "User is disabled"}
+	}
+
+	return &user, nil
+}
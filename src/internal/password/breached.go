@@ -0,0 +1,70 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const prefixLength = 5
+
+// BreachedList holds SHA-1 password hashes in the Have I Been Pwned range
+// format: buckets keyed by the first five hex characters, each holding the
+// remaining suffixes. Lines may carry a ":count" suffix, which is ignored.
+type BreachedList struct {
+	buckets map[string]map[string]struct{}
+}
+
+func LoadBreachedList(path string) (*BreachedList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	list := &BreachedList{buckets: map[string]map[string]struct{}{}}
+
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		hash, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if hash == "" || strings.HasPrefix(hash, "#") {
+			continue
+		}
+
+		hash = strings.ToUpper(hash)
+		if len(hash) != sha1.Size*2 {
+			return nil, fmt.Errorf("%s:%d: invalid SHA-1 hash", path, line)
+		}
+
+		prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+		if list.buckets[prefix] == nil {
+			list.buckets[prefix] = map[string]struct{}{}
+		}
+		list.buckets[prefix][suffix] = struct{}{}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (l *BreachedList) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	bucket, ok := l.buckets[hash[:prefixLength]]
+	if !ok {
+		return false
+	}
+
+	_, found := bucket[hash[prefixLength:]]
+	return found
+}
@@ -0,0 +1,102 @@
+package password
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gaspartv/api.ecommerce/src/config"
+)
+
+// bcryptMaxBytes is the input size bcrypt actually hashes; anything after it
+// is silently ignored, so longer passwords are rejected instead.
+const bcryptMaxBytes = 72
+
+type Violation struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type Policy struct {
+	MinLength     int
+	MaxBytes      int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	breached      *BreachedList
+}
+
+func NewPolicy(env *config.Env) (*Policy, error) {
+	policy := &Policy{
+		MinLength:     env.PasswordMinLength,
+		MaxBytes:      min(env.PasswordMaxBytes, bcryptMaxBytes),
+		RequireUpper:  env.PasswordRequireUpper,
+		RequireLower:  env.PasswordRequireLower,
+		RequireDigit:  env.PasswordRequireDigit,
+		RequireSymbol: env.PasswordRequireSymbol,
+	}
+
+	if env.PasswordBreachedFile != "" {
+		breached, err := LoadBreachedList(env.PasswordBreachedFile)
+		if err != nil {
+			return nil, err
+		}
+		policy.breached = breached
+	}
+
+	return policy, nil
+}
+
+func (p *Policy) Validate(field string, password string) []Violation {
+	var violations []Violation
+
+	add := func(rule string, message string) {
+		violations = append(violations, Violation{Field: field, Rule: rule, Message: message})
+	}
+
+	if utf8.RuneCountInString(password) < p.MinLength {
+		add("min_length", fmt.Sprintf("Password must have at least %d characters", p.MinLength))
+	}
+
+	if len(password) > p.MaxBytes {
+		add("max_length", fmt.Sprintf("Password must have at most %d bytes", p.MaxBytes))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	if p.RequireUpper && !hasUpper {
+		add("uppercase", "Password must contain an uppercase letter")
+	}
+
+	if p.RequireLower && !hasLower {
+		add("lowercase", "Password must contain a lowercase letter")
+	}
+
+	if p.RequireDigit && !hasDigit {
+		add("digit", "Password must contain a digit")
+	}
+
+	if p.RequireSymbol && !hasSymbol {
+		add("symbol", "Password must contain a symbol")
+	}
+
+	if p.breached != nil && p.breached.Contains(password) {
+		add("breached", "Password has appeared in a data breach, choose a different one")
+	}
+
+	return violations
+}
@@ -0,0 +1,90 @@
+package password
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func rules(violations []Violation) []string {
+	names := []string{}
+	for _, violation := range violations {
+		names = append(names, violation.Rule)
+	}
+	return names
+}
+
+func TestPolicyValidate(t *testing.T) {
+	strict := &Policy{
+		MinLength:     8,
+		MaxBytes:      bcryptMaxBytes,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+	}
+
+	tests := []struct {
+		name     string
+		policy   *Policy
+		password string
+		want     []string
+	}{
+		{"valid", strict, "Correct-Horse-9", []string{}},
+		{"too short", strict, "Ab1!", []string{"min_length"}},
+		{"length counts characters", strict, "Çãoéíó1!", []string{}},
+		{"too long for bcrypt", strict, "Aa1!" + strings.Repeat("x", 69), []string{"max_length"}},
+		{"missing classes", strict, "lowercaseonly", []string{"uppercase", "digit", "symbol"}},
+		{"space is a symbol", strict, "Correct Horse 9", []string{}},
+		{"relaxed", &Policy{MinLength: 4, MaxBytes: bcryptMaxBytes}, "abcd", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rules(tt.policy.Validate("password", tt.password)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate(%q) = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyBreached(t *testing.T) {
+	sum := sha1.Sum([]byte("Password123!"))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	content := "# comment\n\n" + strings.ToLower(hash) + ":42\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := LoadBreachedList(path)
+	if err != nil {
+		t.Fatalf("LoadBreachedList: %v", err)
+	}
+
+	policy := &Policy{MinLength: 8, MaxBytes: bcryptMaxBytes, breached: list}
+
+	if got := rules(policy.Validate("password", "Password123!")); !reflect.DeepEqual(got, []string{"breached"}) {
+		t.Errorf("breached password: got %v", got)
+	}
+
+	if got := rules(policy.Validate("password", "Password1234!")); len(got) != 0 {
+		t.Errorf("safe password: got %v", got)
+	}
+}
+
+func TestLoadBreachedListRejectsInvalidHash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(path, []byte("not-a-hash\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadBreachedList(path); err == nil {
+		t.Fatal("expected an error for an invalid line")
+	}
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/gaspartv/api.ecommerce/src/config"
+	"github.com/gaspartv/api.ecommerce/src/internal/handler"
+	"github.com/gaspartv/api.ecommerce/src/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func CartRoutes(router *gin.Engine, db *gorm.DB, env *config.Env) {
+	cartHandler := handler.NewCartHandler(db, env)
+	cartGroup := router.Group("carts", middleware.OptionalAuth(db, env))
+	{
+		cartGroup.GET("current", cartHandler.Current)
+		cartGroup.POST("add-item", cartHandler.AddItem)
+		cartGroup.PATCH("update-item", cartHandler.UpdateItem)
+		cartGroup.DELETE("remove-item", cartHandler.RemoveItem)
+		cartGroup.DELETE("clear", cartHandler.Clear)
+	}
+}
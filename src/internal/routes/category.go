@@ -1,24 +1,32 @@
 package routes
 
 import (
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/gaspartv/api.ecommerce/src/config"
+	"github.com/gaspartv/api.ecommerce/src/external/storage"
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
 	"github.com/gaspartv/api.ecommerce/src/internal/handler"
+	"github.com/gaspartv/api.ecommerce/src/internal/middleware"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func CategoryRoutes(router *gin.Engine, db *gorm.DB, r2 *s3.Client, env *config.Env) {
-	categoryHandler := handler.NewCategoryHandler(db, r2, env)
+func CategoryRoutes(router *gin.Engine, db *gorm.DB, store storage.ObjectStore, env *config.Env) {
+	categoryHandler := handler.NewCategoryHandler(db, store, env)
+	auth := middleware.Auth(db, env)
+	canWrite := requirePermission(entity.PermissionCatalogWrite)
+	canDelete := requirePermission(entity.PermissionCatalogDelete)
 	categoryGroup := router.Group("categories")
 	{
-		categoryGroup.POST("create", categoryHandler.Create)
+		categoryGroup.POST("create", auth, canWrite, categoryHandler.Create)
 		categoryGroup.GET("list", categoryHandler.List)
 		categoryGroup.GET("find", categoryHandler.GetByID)
-		categoryGroup.PATCH("edit", categoryHandler.Edit)
-		categoryGroup.DELETE("delete", categoryHandler.Delete)
-		categoryGroup.PATCH("disable", categoryHandler.Disable)
-		categoryGroup.PATCH("change-image", categoryHandler.ChangeImage)
+		categoryGroup.GET("by-slug/:slug", categoryHandler.GetBySlug)
+		categoryGroup.PATCH("edit", auth, canWrite, categoryHandler.Edit)
+		categoryGroup.DELETE("delete", auth, canDelete, categoryHandler.Delete)
+		categoryGroup.PATCH("disable", auth, canWrite, categoryHandler.Disable)
+		categoryGroup.PATCH("change-image", auth, canWrite, categoryHandler.ChangeImage)
 		categoryGroup.GET("list-select", categoryHandler.ListSelect)
+		categoryGroup.GET("tree", categoryHandler.Tree)
+		categoryGroup.GET("breadcrumbs", categoryHandler.Breadcrumbs)
 	}
 }
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gaspartv/api.ecommerce/src/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// requirePermission must be registered after middleware.Auth.
+func requirePermission(permission string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user := middleware.CurrentUser(ctx)
+		if user == nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization token is required"})
+			return
+		}
+
+		if !user.HasPermission(permission) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+			return
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/gaspartv/api.ecommerce/src/config"
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"github.com/gaspartv/api.ecommerce/src/internal/handler"
+	"github.com/gaspartv/api.ecommerce/src/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func OrderRoutes(router *gin.Engine, db *gorm.DB, env *config.Env) {
+	orderHandler := handler.NewOrderHandler(db, env)
+	canManage := requirePermission(entity.PermissionOrdersManage)
+	orderGroup := router.Group("orders", middleware.Auth(db, env))
+	{
+		orderGroup.POST("start-checkout", orderHandler.StartCheckout)
+		orderGroup.POST("checkout", orderHandler.Checkout)
+		orderGroup.GET("list", orderHandler.List)
+		orderGroup.GET("find", orderHandler.GetByID)
+		orderGroup.PATCH("change-status", canManage, orderHandler.ChangeStatus)
+	}
+}
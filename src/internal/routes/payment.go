@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"github.com/gaspartv/api.ecommerce/src/config"
+	"github.com/gaspartv/api.ecommerce/src/external/payment"
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"github.com/gaspartv/api.ecommerce/src/internal/handler"
+	"github.com/gaspartv/api.ecommerce/src/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func PaymentRoutes(router *gin.Engine, db *gorm.DB, provider payment.PaymentProvider, env *config.Env) {
+	paymentHandler := handler.NewPaymentHandler(db, provider, env)
+	auth := middleware.Auth(db, env)
+	canManage := requirePermission(entity.PermissionOrdersManage)
+	paymentGroup := router.Group("payments")
+	{
+		paymentGroup.POST("authorize", auth, paymentHandler.Authorize)
+		paymentGroup.POST("capture", auth, canManage, paymentHandler.Capture)
+		paymentGroup.POST("refund", auth, canManage, paymentHandler.Refund)
+		paymentGroup.POST("webhook", paymentHandler.Webhook)
+	}
+}
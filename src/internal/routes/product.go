@@ -1,22 +1,46 @@
 package routes
 
 import (
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/gaspartv/api.ecommerce/src/config"
+	"github.com/gaspartv/api.ecommerce/src/external/storage"
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
 	"github.com/gaspartv/api.ecommerce/src/internal/handler"
+	"github.com/gaspartv/api.ecommerce/src/internal/middleware"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func ProductRoutes(router *gin.Engine, db *gorm.DB, r2 *s3.Client, env *config.Env) {
-	productHandler := handler.NewProductHandler(db, r2, env)
+func ProductRoutes(router *gin.Engine, db *gorm.DB, store storage.ObjectStore, env *config.Env) {
+	productHandler := handler.NewProductHandler(db, store, env)
+	auth := middleware.Auth(db, env)
+	canWrite := requirePermission(entity.PermissionCatalogWrite)
+	canDelete := requirePermission(entity.PermissionCatalogDelete)
 	productGroup := router.Group("products")
 	{
-		productGroup.POST("create", productHandler.Create)
+		productGroup.POST("create", auth, canWrite, productHandler.Create)
 		productGroup.GET("list", productHandler.List)
-		productGroup.PATCH("edit", productHandler.Edit)
-		productGroup.DELETE("delete", productHandler.Delete)
-		productGroup.PATCH("disable", productHandler.Disable)
-		productGroup.PATCH("change-image", productHandler.ChangeImage)
+		productGroup.GET("find", middleware.OptionalAuth(db, env), productHandler.GetByID)
+		productGroup.GET("by-slug/:slug", productHandler.GetBySlug)
+		productGroup.PATCH("edit", auth, canWrite, productHandler.Edit)
+		productGroup.DELETE("delete", auth, canDelete, productHandler.Delete)
+		productGroup.PATCH("disable", auth, canWrite, productHandler.Disable)
+		productGroup.PATCH("change-image", auth, canWrite, productHandler.ChangeImage)
+		productGroup.POST("upload-images", auth, canWrite, productHandler.UploadImages)
+		productGroup.GET("images", productHandler.ListImages)
+		productGroup.PATCH("reorder-images", auth, canWrite, productHandler.ReorderImages)
+		productGroup.PATCH("edit-image", auth, canWrite, productHandler.EditImage)
+		productGroup.DELETE("delete-image", auth, canDelete, productHandler.DeleteImage)
+		productGroup.POST("adjust-stock", auth, canWrite, productHandler.AdjustStock)
+		productGroup.GET("stock-history", auth, canWrite, productHandler.StockHistory)
+		productGroup.GET("low-stock", auth, canWrite, productHandler.LowStock)
+		productGroup.POST("import", auth, canWrite, productHandler.Import)
+		productGroup.GET("import-status", auth, canWrite, productHandler.ImportStatus)
+		productGroup.POST("create-option", auth, canWrite, productHandler.CreateOption)
+		productGroup.DELETE("delete-option", auth, canDelete, productHandler.DeleteOption)
+		productGroup.POST("create-variant", auth, canWrite, productHandler.CreateVariant)
+		productGroup.PATCH("edit-variant", auth, canWrite, productHandler.EditVariant)
+		productGroup.DELETE("delete-variant", auth, canDelete, productHandler.DeleteVariant)
+		productGroup.PATCH("disable-variant", auth, canWrite, productHandler.DisableVariant)
+		productGroup.PATCH("change-variant-image", auth, canWrite, productHandler.ChangeVariantImage)
 	}
 }
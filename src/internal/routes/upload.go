@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gaspartv/api.ecommerce/src/config"
+	"github.com/gaspartv/api.ecommerce/src/external/storage"
+	"github.com/gaspartv/api.ecommerce/src/internal/handler"
+	"github.com/gaspartv/api.ecommerce/src/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func UploadRoutes(router *gin.Engine, db *gorm.DB, store storage.ObjectStore, env *config.Env) {
+	uploadHandler := handler.NewUploadHandler(db, store, env)
+	uploadGroup := router.Group("uploads", middleware.Auth(db, env))
+	{
+		uploadGroup.POST("presign", uploadHandler.Presign)
+		uploadGroup.POST("complete", uploadHandler.Complete)
+	}
+}
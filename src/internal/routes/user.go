@@ -1,22 +1,37 @@
 package routes
 
 import (
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/gaspartv/api.ecommerce/src/config"
+	"github.com/gaspartv/api.ecommerce/src/external/mailer"
+	"github.com/gaspartv/api.ecommerce/src/external/storage"
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
 	"github.com/gaspartv/api.ecommerce/src/internal/handler"
+	"github.com/gaspartv/api.ecommerce/src/internal/middleware"
+	"github.com/gaspartv/api.ecommerce/src/internal/password"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func UserRoutes(router *gin.Engine, db *gorm.DB, r2 *s3.Client, env *config.Env) {
-	userHandler := handler.NewUserHandler(db, r2, env)
+func UserRoutes(router *gin.Engine, db *gorm.DB, store storage.ObjectStore, mailer mailer.Mailer, passwords *password.Policy, env *config.Env) {
+	userHandler := handler.NewUserHandler(db, store, mailer, passwords, env)
+	auth := middleware.Auth(db, env)
+	canManage := requirePermission(entity.PermissionUsersManage)
 	userGroup := router.Group("users")
 	{
 		userGroup.POST("create", userHandler.Create)
-		// userGroup.GET("list", userHandler.List)
-		// userGroup.PATCH("edit", userHandler.Edit)
-		// userGroup.DELETE("delete", userHandler.Delete)
-		// userGroup.PATCH("disable", userHandler.Disable)
-		// userGroup.PATCH("change-profile-image", userHandler.ChangeProfileImage)
+		userGroup.POST("login", userHandler.Login)
+		userGroup.POST("refresh", userHandler.Refresh)
+		userGroup.POST("logout", userHandler.Logout)
+		userGroup.GET("verify-email", userHandler.VerifyEmail)
+		userGroup.POST("resend-verification", userHandler.ResendVerification)
+		userGroup.POST("forgot-password", userHandler.ForgotPassword)
+		userGroup.POST("reset-password", userHandler.ResetPassword)
+		userGroup.PATCH("change-password", auth, userHandler.ChangePassword)
+		userGroup.GET("list", auth, canManage, userHandler.List)
+		userGroup.PATCH("edit", auth, userHandler.Edit)
+		userGroup.DELETE("delete", auth, canManage, userHandler.Delete)
+		userGroup.PATCH("disable", auth, canManage, userHandler.Disable)
+		userGroup.PATCH("change-profile-image", auth, userHandler.ChangeProfileImage)
+		userGroup.PATCH("change-role", auth, canManage, userHandler.ChangeRole)
 	}
 }
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/gaspartv/api.ecommerce/src/config"
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"github.com/gaspartv/api.ecommerce/src/internal/handler"
+	"github.com/gaspartv/api.ecommerce/src/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func WarehouseRoutes(router *gin.Engine, db *gorm.DB, env *config.Env) {
+	warehouseHandler := handler.NewWarehouseHandler(db, env)
+	canWrite := requirePermission(entity.PermissionCatalogWrite)
+	canDelete := requirePermission(entity.PermissionCatalogDelete)
+	warehouseGroup := router.Group("warehouses", middleware.Auth(db, env))
+	{
+		warehouseGroup.POST("create", canWrite, warehouseHandler.Create)
+		warehouseGroup.GET("list", canWrite, warehouseHandler.List)
+		warehouseGroup.PATCH("edit", canWrite, warehouseHandler.Edit)
+		warehouseGroup.DELETE("delete", canDelete, warehouseHandler.Delete)
+		warehouseGroup.PATCH("disable", canWrite, warehouseHandler.Disable)
+		warehouseGroup.POST("transfer", canWrite, warehouseHandler.Transfer)
+		warehouseGroup.GET("stock", canWrite, warehouseHandler.Stock)
+	}
+}
@@ -0,0 +1,52 @@
+package seed
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gaspartv/api.ecommerce/src/config"
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"gorm.io/gorm"
+)
+
+func Roles(db *gorm.DB, env *config.Env) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for roleName, permissionNames := range entity.DefaultRolePermissions {
+			// The new row goes in Attrs, not as the destination: GORM would add
+			// its pre-generated ID to the lookup and never find the seeded row.
+			role := &entity.Role{}
+			if err := tx.Where("name = ?", roleName).Attrs(entity.NewRole(roleName)).FirstOrCreate(role).Error; err != nil {
+				return err
+			}
+
+			for _, permissionName := range permissionNames {
+				permission := &entity.Permission{}
+				if err := tx.Where("name = ?", permissionName).Attrs(entity.NewPermission(permissionName)).FirstOrCreate(permission).Error; err != nil {
+					return err
+				}
+
+				if err := tx.Model(role).Association("Permissions").Append(permission); err != nil {
+					return err
+				}
+			}
+		}
+
+		if env.AdminEmail == "" {
+			return nil
+		}
+
+		var admin entity.Role
+		if err := tx.Where("name = ?", entity.RoleAdmin).First(&admin).Error; err != nil {
+			return err
+		}
+
+		err := tx.Model(&entity.User{}).
+			Where("email = ? AND deleted_at IS NULL", strings.ToLower(env.AdminEmail)).
+			Update("role_id", admin.ID).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,40 @@
+package seed
+
+import (
+	"log"
+
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"github.com/gaspartv/api.ecommerce/src/internal/inventory"
+	"gorm.io/gorm"
+)
+
+// Warehouses makes sure a warehouse is active, so stock movements always
+// have somewhere to land. The default warehouse is enabled again when an
+// admin disabled it, or created when it does not exist.
+func Warehouses(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&entity.Warehouse{}).Where("disabled_at IS NULL").Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return nil
+	}
+
+	result := db.Model(&entity.Warehouse{}).
+		Where("code = ? AND deleted_at IS NULL", inventory.DefaultWarehouseCode).
+		Update("disabled_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		log.Printf("Depósito padrão %s reativado, pois nenhum depósito estava ativo", inventory.DefaultWarehouseCode)
+		return nil
+	}
+
+	return db.Create(entity.NewWarehouse(entity.WarehouseCreate{
+		Name: "Main warehouse",
+		Code: inventory.DefaultWarehouseCode,
+	})).Error
+}
@@ -0,0 +1,120 @@
+package slug
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gaspartv/api.ecommerce/src/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var transliterator = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+	"&", " e ",
+)
+
+// Make turns a name into a lowercase ASCII slug, transliterating
+// Portuguese accents: "Calção Térmico" becomes "calcao-termico".
+func Make(name string) string {
+	value := transliterator.Replace(strings.ToLower(strings.TrimSpace(name)))
+
+	var builder strings.Builder
+	dash := false
+	for _, r := range value {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			builder.WriteRune(r)
+			dash = false
+			continue
+		}
+
+		if !dash && builder.Len() > 0 {
+			builder.WriteByte('-')
+			dash = true
+		}
+	}
+
+	result := strings.TrimSuffix(builder.String(), "-")
+	if len(result) > 200 {
+		result = strings.TrimSuffix(result[:200], "-")
+	}
+
+	if result == "" {
+		return "item"
+	}
+
+	return result
+}
+
+// Unique returns the slug of name that is free in table, adding a numeric
+// suffix when needed. Slugs kept in the history of another row stay taken so
+// their redirects keep working. excludeID is the row being renamed.
+func Unique(tx *gorm.DB, table string, entityType string, name string, excludeID string) (string, error) {
+	base := Make(name)
+	candidate := base
+
+	for suffix := 2; ; suffix++ {
+		var count int64
+		if err := tx.Table(table).
+			Where("slug = ? AND id <> ? AND deleted_at IS NULL", candidate, excludeID).
+			Count(&count).Error; err != nil {
+			return "", err
+		}
+
+		if count == 0 {
+			if err := tx.Table("slug_histories").
+				Where("entity_type = ? AND slug = ? AND entity_id <> ?", entityType, candidate, excludeID).
+				Count(&count).Error; err != nil {
+				return "", err
+			}
+		}
+
+		if count == 0 {
+			return candidate, nil
+		}
+
+		candidate = fmt.Sprintf("%s-%d", base, suffix)
+	}
+}
+
+// Rename gives a renamed row the slug of its new name and keeps the old slug
+// in the history. It returns the current slug, which is unchanged when the
+// new name slugs to the same value.
+func Rename(tx *gorm.DB, table string, entityType string, id string, oldSlug string, name string) (string, error) {
+	if Make(name) == oldSlug {
+		return oldSlug, nil
+	}
+
+	newSlug, err := Unique(tx, table, entityType, name, id)
+	if err != nil {
+		return "", err
+	}
+
+	if newSlug == oldSlug {
+		return oldSlug, nil
+	}
+
+	// Renaming back to an earlier name takes its slug out of the history.
+	if err := tx.Where("entity_type = ? AND entity_id = ? AND slug = ?", entityType, id, newSlug).
+		Delete(&entity.SlugHistory{}).Error; err != nil {
+		return "", err
+	}
+
+	if oldSlug != "" {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(entity.NewSlugHistory(entityType, id, oldSlug)).Error; err != nil {
+			return "", err
+		}
+	}
+
+	if err := tx.Table(table).Where("id = ?", id).Update("slug", newSlug).Error; err != nil {
+		return "", err
+	}
+
+	return newSlug, nil
+}
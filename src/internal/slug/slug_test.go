@@ -0,0 +1,30 @@
+package slug
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Calção Térmico", "calcao-termico"},
+		{"  Camiseta   Básica  ", "camiseta-basica"},
+		{"Pão & Café", "pao-e-cafe"},
+		{"Tênis 42/43 (Preto)", "tenis-42-43-preto"},
+		{"AÇÃO", "acao"},
+		{"---", "item"},
+		{"", "item"},
+		{"日本語", "item"},
+		{strings.Repeat("a", 199) + " b", strings.Repeat("a", 199)},
+		{strings.Repeat("ab", 150), strings.Repeat("ab", 100)},
+	}
+
+	for _, tt := range tests {
+		if got := Make(tt.name); got != tt.want {
+			t.Errorf("Make(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}